@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/tune"
+)
+
+var (
+	config   = tune.NewBookConfig()
+	bots     string
+	bookPath string
+)
+
+func init() {
+	flag.IntVar(&config.Games, "games", config.Games, "count of self-play games")
+	flag.IntVar(&config.Rows, "rows", config.Rows, "rows of the boards")
+	flag.IntVar(&config.Cols, "cols", config.Cols, "columns of the boards")
+	flag.Int64Var(&config.Seed, "seed", config.Seed, "seed of the boards and the bots")
+	flag.IntVar(&config.Workers, "workers", config.Workers, "count of games played in parallel")
+	flag.IntVar(&config.Rounds, "rounds", config.Rounds, "count of the first rounds of every game added to the book")
+	flag.IntVar(&config.Boards, "boards", config.Boards, "count of distinct boards the games cycle through")
+	flag.StringVar(&bots, "bots", strings.Join(config.Bots, ","), "comma-separated names of the bots by their seats")
+	flag.StringVar(&bookPath, "book-path", "book.json", "file of the opening book, extended if it exists")
+}
+
+func main() {
+	flag.Parse()
+	config.Bots = strings.Split(bots, ",")
+
+	book, err := loadBook(bookPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := tune.LearnBook(ctx, config, book); err != nil {
+		log.Fatal(err)
+	}
+
+	file, err := os.Create(bookPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	if err := book.Save(file); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("book has %d positions", book.Len())
+}
+
+// loadBook reads the opening book from the file, or creates an empty one if it does not exist.
+func loadBook(path string) (*bot.Book, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return bot.NewBook(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return bot.LoadBook(file)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/Vacym/neighbors-force/internal/conformance"
+)
+
+var (
+	config     = conformance.NewConfig()
+	jsonOutput bool
+)
+
+func init() {
+	flag.StringVar(&config.Endpoint, "endpoint", config.Endpoint, "base URL of the bot server")
+	flag.DurationVar(&config.Timeout, "timeout", config.Timeout, "max time of a single response")
+	flag.BoolVar(&config.Legacy, "legacy", config.Legacy, "check the legacy /ai_attack and /ai_upgrade endpoints")
+	flag.IntVar(&config.Games, "games", config.Games, "count of full games against the greedy bot")
+	flag.Int64Var(&config.Seed, "seed", config.Seed, "seed of the boards")
+	flag.BoolVar(&jsonOutput, "json", false, "write the report as JSON")
+}
+
+func main() {
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report := conformance.Run(ctx, config)
+
+	var err error
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = report.Write(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if report.Failed() > 0 {
+		os.Exit(1)
+	}
+}
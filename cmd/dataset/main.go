@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/Vacym/neighbors-force/internal/tune"
+)
+
+var (
+	config  = tune.NewDatasetConfig()
+	bots    string
+	outPath string
+)
+
+func init() {
+	flag.IntVar(&config.Games, "games", config.Games, "count of self-play games")
+	flag.IntVar(&config.Rows, "rows", config.Rows, "rows of the boards")
+	flag.IntVar(&config.Cols, "cols", config.Cols, "columns of the boards")
+	flag.Int64Var(&config.Seed, "seed", config.Seed, "seed of the first game, the next games are seeded by the following numbers")
+	flag.IntVar(&config.Workers, "workers", config.Workers, "count of games played in parallel")
+	flag.StringVar(&bots, "bots", strings.Join(config.Bots, ","), "comma-separated names of the bots by their seats")
+	flag.StringVar(&outPath, "out", "", "file the records are written to, stdout if empty")
+}
+
+func main() {
+	flag.Parse()
+	config.Bots = strings.Split(bots, ",")
+
+	var out io.Writer = os.Stdout
+	if outPath != "" {
+		file, err := os.Create(outPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		out = file
+	}
+	buffered := bufio.NewWriter(out)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := tune.ExportDataset(ctx, config, buffered); err != nil {
+		log.Fatal(err)
+	}
+	if err := buffered.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/neural"
+	"github.com/Vacym/neighbors-force/internal/tune"
+)
+
+var (
+	train       = neural.NewTrainConfig()
+	match       = tune.NewConfig()
+	hidden      string
+	datasetPath string
+	networkPath string
+	baseline    string
+)
+
+func init() {
+	flag.StringVar(&datasetPath, "dataset", "dataset.jsonl", "file of the exported self-play positions")
+	flag.StringVar(&networkPath, "network-path", "network.json", "file the trained network is written to")
+	flag.IntVar(&match.Rows, "rows", match.Rows, "rows of the boards of the positions")
+	flag.IntVar(&match.Cols, "cols", match.Cols, "columns of the boards of the positions")
+	flag.StringVar(&hidden, "hidden", "64,32", "comma-separated sizes of the hidden layers")
+	flag.IntVar(&train.Epochs, "epochs", train.Epochs, "count of passes over the positions")
+	flag.IntVar(&train.BatchSize, "batch", train.BatchSize, "count of positions per update of the weights")
+	flag.Float64Var(&train.LearningRate, "rate", train.LearningRate, "learning rate")
+	flag.Int64Var(&train.Seed, "seed", train.Seed, "seed of the weights, the order of the positions and the benchmark boards")
+	flag.StringVar(&baseline, "baseline", "greedy", "bot the trained network is benchmarked against")
+	flag.IntVar(&match.Games, "games", match.Games, "count of benchmark games, 0 to skip the benchmark")
+	flag.IntVar(&match.Workers, "workers", match.Workers, "count of benchmark games played in parallel")
+}
+
+func main() {
+	flag.Parse()
+
+	sizes, err := parseSizes(hidden)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	samples, err := readSamples(datasetPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("read %d positions", len(samples))
+
+	network := neural.NewNetwork(match.Rows, match.Cols, sizes, train.Seed)
+	loss, err := network.Train(samples, train)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("trained %d epochs, loss %.4f", train.Epochs, loss)
+
+	if err := saveNetwork(networkPath, network); err != nil {
+		log.Fatal(err)
+	}
+
+	if match.Games == 0 {
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if _, err := bot.New(baseline); err != nil {
+		log.Fatal(err)
+	}
+	neuralBot := func() bot.Strategy { return bot.NewNeural(network) }
+	opponent := func() bot.Strategy {
+		strategy, _ := bot.New(baseline)
+		return strategy
+	}
+	match.Seed = train.Seed
+	score, err := tune.Match(ctx, match, neuralBot, opponent, match.Seed)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("network scores %.2f against %q in %d games", score, baseline, match.Games)
+}
+
+// parseSizes parses the comma-separated sizes of the hidden layers.
+func parseSizes(s string) ([]int, error) {
+	var sizes []int
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		size, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		if size < 1 {
+			return nil, fmt.Errorf("incorrect size of a hidden layer: %d", size)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+// readSamples reads the positions of the dataset file.
+func readSamples(path string) ([]neural.Sample, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return neural.ReadSamples(file, match.Rows, match.Cols)
+}
+
+// saveNetwork writes the network to the file.
+func saveNetwork(path string, network *neural.Network) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return network.Save(file)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/tune"
+)
+
+var (
+	config       = tune.NewConfig()
+	profilesPath string
+	startName    string
+	outName      string
+	outPath      string
+)
+
+func init() {
+	flag.IntVar(&config.Iterations, "iterations", config.Iterations, "count of tuning iterations")
+	flag.IntVar(&config.Games, "games", config.Games, "count of games per evaluation")
+	flag.IntVar(&config.Rows, "rows", config.Rows, "rows of the boards")
+	flag.IntVar(&config.Cols, "cols", config.Cols, "columns of the boards")
+	flag.Int64Var(&config.Seed, "seed", config.Seed, "seed of the boards and the perturbations")
+	flag.IntVar(&config.Workers, "workers", config.Workers, "count of games played in parallel")
+	flag.StringVar(&config.Baseline, "baseline", config.Baseline, "bot the tuned profile is compared with")
+	flag.StringVar(&config.Checkpoint, "checkpoint", "", "file the progress is saved to and resumed from")
+	flag.Float64Var(&config.StepSize, "step", config.StepSize, "initial size of the weight update")
+	flag.Float64Var(&config.Delta, "delta", config.Delta, "initial size of the weight perturbation")
+
+	flag.StringVar(&profilesPath, "profiles-path", "", "file of profiles to start from, the built-in profiles if empty")
+	flag.StringVar(&startName, "start", "balanced", "name of the profile to start from")
+	flag.StringVar(&outName, "name", "tuned", "name of the tuned profile")
+	flag.StringVar(&outPath, "out", "", "file the tuned profile is written to, stdout if empty")
+}
+
+func main() {
+	flag.Parse()
+
+	profile, err := startProfile()
+	if err != nil {
+		log.Fatal(err)
+	}
+	profile.Name = outName
+	profile.Description = fmt.Sprintf("Tuned from %q by self-play", startName)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	before, err := tune.WinRate(ctx, config, profile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("start profile scores %.2f against %q", before, config.Baseline)
+
+	profile, err = tune.Tune(ctx, config, profile, os.Stderr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	after, err := tune.WinRate(ctx, config, profile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("tuned profile scores %.2f against %q", after, config.Baseline)
+
+	out := os.Stdout
+	if outPath != "" {
+		out, err = os.Create(outPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+	}
+
+	comment := fmt.Sprintf("Win rate %.2f against %q over %d games on %dx%d boards", after, config.Baseline, config.Games, config.Rows, config.Cols)
+	if err := tune.WriteProfile(out, profile, comment); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// startProfile returns the profile the tuning starts from.
+func startProfile() (bot.Profile, error) {
+	profiles := bot.BuiltinProfiles()
+	if profilesPath != "" {
+		file, err := os.Open(profilesPath)
+		if err != nil {
+			return bot.Profile{}, err
+		}
+		defer file.Close()
+
+		profiles, err = bot.LoadProfiles(file)
+		if err != nil {
+			return bot.Profile{}, err
+		}
+	}
+
+	for _, profile := range profiles {
+		if profile.Name == startName {
+			return profile, nil
+		}
+	}
+	return bot.Profile{}, fmt.Errorf("unknown profile %q", startName)
+}
@@ -1,13 +1,20 @@
 package apiserver
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
 
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/neural"
 	"github.com/Vacym/neighbors-force/internal/proxyserver"
 	"github.com/gorilla/sessions"
 	"github.com/sirupsen/logrus"
 )
 
+var errDuplicateBot = errors.New("bot name is already taken")
+
 func Start(config *proxyserver.Config) error {
 	sessionStore := sessions.NewCookieStore([]byte(config.SessionKey))
 
@@ -16,7 +23,178 @@ func Start(config *proxyserver.Config) error {
 		return err
 	}
 
+	if err := configureMCTS(config); err != nil {
+		return err
+	}
+	if err := registerProfiles(config.BotProfiles); err != nil {
+		return err
+	}
+	if err := registerEngines(config.Engines); err != nil {
+		return err
+	}
+	if err := registerNeural(config.NeuralNetwork); err != nil {
+		return err
+	}
+
 	s := newServer(sessionStore, logLevel)
+	s.botBudget = bot.Budget{
+		Move: config.BotMoveBudget,
+		Turn: config.BotTurnBudget,
+	}
+	s.botRest = config.BotRestBudget
+	if config.SessionIdle > 0 {
+		go s.evictIdleUsers(config.SessionIdle)
+	}
+	s.bots.trace = config.BotTrace
+	s.bots.adaptiveSkill = config.AdaptiveSkill
+	if config.OpeningBook != "" {
+		book, err := loadBook(config.OpeningBook)
+		if err != nil {
+			return err
+		}
+		s.bots.book = openingBook{
+			book:     book,
+			level:    config.OpeningBookLevel,
+			minPlays: config.OpeningBookMinPlays,
+		}
+	}
+	if config.HintBot != "" {
+		if _, err := bot.New(config.HintBot); err != nil {
+			return err
+		}
+		s.hintBot = config.HintBot
+	}
+	if len(config.BotAPIEndpoints) > 0 {
+		s.bots.external.endpoints = config.BotAPIEndpoints
+	}
+	s.bots.external.options = []bot.APIOption{
+		bot.WithRequestTimeout(config.BotAPITimeout),
+		bot.WithRetries(config.BotAPIRetries),
+		bot.WithMaxActions(config.BotAPIMaxActions),
+		bot.WithFallback(config.BotAPIFallback),
+		bot.WithLegacyProtocol(config.BotAPILegacy),
+	}
 
 	return http.ListenAndServe(config.BindAddrApi, s)
 }
+
+// configureMCTS sets the search options of the "mcts" bot, the zero ones are left default.
+func configureMCTS(config *proxyserver.Config) error {
+	var options []bot.MCTSOption
+	if config.MCTSIterations > 0 {
+		options = append(options, bot.WithIterations(config.MCTSIterations))
+	}
+	if config.MCTSPlayoutTurns > 0 {
+		options = append(options, bot.WithPlayoutTurns(config.MCTSPlayoutTurns))
+	}
+	if config.MCTSPlayout != "" {
+		if _, err := bot.New(config.MCTSPlayout); err != nil {
+			return err
+		}
+		options = append(options, bot.WithPlayout(config.MCTSPlayout))
+	}
+
+	bot.ConfigureMCTS(options...)
+	return nil
+}
+
+// registerEngines makes the bots running as local processes available by their names.
+func registerEngines(engines []proxyserver.EngineConfig) error {
+	for _, engine := range engines {
+		if err := checkBotName(engine.Name); err != nil {
+			return err
+		}
+
+		engine := engine
+		var options []bot.EngineOption
+		if engine.MoveTimeout > 0 {
+			options = append(options, bot.WithMoveTimeout(engine.MoveTimeout))
+		}
+		if sandbox := engine.Sandbox; sandbox != nil {
+			options = append(options, bot.WithSandbox(bot.SandboxLimits{
+				CPUTime:   sandbox.CPUTime,
+				Memory:    sandbox.MemoryMB << 20,
+				FileSize:  sandbox.FileMB << 20,
+				OpenFiles: sandbox.OpenFiles,
+				Output:    sandbox.OutputMB << 20,
+			}))
+		}
+
+		bot.Register(bot.Info{
+			Name:        engine.Name,
+			Description: engine.Description,
+			Level:       -1,
+		}, func() bot.Strategy { return bot.NewEngine(engine.Path, engine.Args, options...) })
+	}
+	return nil
+}
+
+// registerNeural makes the bot with the value network from the file available as "neural".
+func registerNeural(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := checkBotName("neural"); err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	network, err := neural.LoadNetwork(file)
+	if err != nil {
+		return err
+	}
+	bot.RegisterNeural("neural", network)
+	return nil
+}
+
+// loadBook reads the opening book from the file.
+func loadBook(path string) (*bot.Book, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return bot.LoadBook(file)
+}
+
+// registerProfiles makes the heuristic bots with the profiles from the file available by their names.
+func registerProfiles(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	profiles, err := bot.LoadProfiles(file)
+	if err != nil {
+		return err
+	}
+
+	for _, profile := range profiles {
+		if err := checkBotName(profile.Name); err != nil {
+			return err
+		}
+		bot.RegisterProfile(profile)
+	}
+	return nil
+}
+
+// checkBotName returns an error if the name is taken by a registered bot.
+func checkBotName(name string) error {
+	for _, info := range bot.List() {
+		if info.Name == name {
+			return fmt.Errorf("%w: %q", errDuplicateBot, name)
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/Vacym/neighbors-force/internal/bot"
 	"github.com/Vacym/neighbors-force/internal/game"
@@ -32,8 +33,12 @@ type apiServer struct {
 	router       *mux.Router
 	testRouter   *mux.Router // Separate router for test handlers
 	sessionStore sessions.Store
-	activeUsers  map[string]*User
+	users        *userRegistry
 	logger       *logrus.Logger
+	botBudget    bot.Budget    // Time limits of the bots' thinking
+	botRest      time.Duration // Time the bots may think over the rest of a game the user is out of
+	bots         botSetup      // Configuration of the bots of new games
+	hintBot      string        // Name of the bot that suggests moves to the users
 }
 
 // newServer creates a new instance of apiServer.
@@ -42,8 +47,20 @@ func newServer(sessionStore sessions.Store, logLevel logrus.Level) *apiServer {
 		router:       mux.NewRouter(),
 		testRouter:   mux.NewRouter(),
 		sessionStore: sessionStore,
-		activeUsers:  make(map[string]*User),
+		users:        newUserRegistry(),
 		logger:       logrus.New(),
+		botBudget: bot.Budget{
+			Move: 2 * time.Second,
+			Turn: 10 * time.Second,
+		},
+		botRest: 30 * time.Second,
+		bots: botSetup{
+			external: externalBots{
+				endpoints: []string{bot.DefaultAPIEndpoint},
+			},
+			adaptiveSkill: 1,
+		},
+		hintBot: "chain",
 	}
 
 	s.logger.SetLevel(logLevel)
@@ -71,14 +88,35 @@ func (s *apiServer) configureRouter() {
 	s.router.HandleFunc("/game/attack", s.handleMakeAttack()).Methods("POST")
 	s.router.HandleFunc("/game/end_attack", s.handleEndAttack()).Methods("POST")
 	s.router.HandleFunc("/game/upgrade", s.handleMakeUpgrade()).Methods("POST")
+	s.router.HandleFunc("/game/hint", s.handleHint()).Methods("GET")
+	s.router.HandleFunc("/game/upgrade_hint", s.handleUpgradeHint()).Methods("GET")
 	s.router.HandleFunc("/game/end_turn", s.handleEndTurn()).Methods("POST")
+	s.router.HandleFunc("/game/pass", s.handlePass()).Methods("POST")
+	s.router.HandleFunc("/game/resign", s.handleResign()).Methods("POST")
+	s.router.HandleFunc("/game/offer_draw", s.handleOfferDraw()).Methods("POST")
+	s.router.HandleFunc("/game/accept_draw", s.handleAcceptDraw()).Methods("POST")
+	s.router.HandleFunc("/game/decline_draw", s.handleDeclineDraw()).Methods("POST")
 	s.router.HandleFunc("/game/get_map", s.handleGetMap()).Methods("GET")
+	s.router.HandleFunc("/game/bot_trace", s.handleBotTrace()).Methods("GET")
+	s.router.HandleFunc("/bots", s.handleListBots()).Methods("GET")
 
 	// Add a test handler, used only in tests.
 	s.testRouter.Use(s.UserMiddleware)
 	s.testRouter.HandleFunc("/test/create_full", s.CreateFullGame()).Methods("POST")
 }
 
+// evictIdleUsers removes the users idle for longer than the timeout every little while. It never returns.
+func (s *apiServer) evictIdleUsers(timeout time.Duration) {
+	ticker := time.NewTicker(min(timeout, time.Minute))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if count := s.users.evictIdle(timeout); count > 0 {
+			s.logger.WithField("count", count).Info("Evicted idle users")
+		}
+	}
+}
+
 // UserMiddleware is a middleware that handles user-related tasks.
 func (s *apiServer) UserMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -96,13 +134,16 @@ func (s *apiServer) UserMiddleware(next http.Handler) http.Handler {
 			session.Save(r, w)
 		}
 
-		user, ok := s.activeUsers[userID]
-		if !ok {
+		user, created := s.users.get(userID)
+		if created {
 			s.logger.Info("Creating new user")
-			user = NewUser()
-			s.activeUsers[userID] = user
 		}
 
+		// The requests of the user are served one at a time, so that its game
+		// is not changed by parallel requests, e.g. a double-clicked attack
+		user.mu.Lock()
+		defer user.mu.Unlock()
+
 		ctx := context.WithValue(r.Context(), ctxKeyUser, user)
 		s.logger.WithField("user_id", userID).Debug("Adding user to context")
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -112,11 +153,14 @@ func (s *apiServer) UserMiddleware(next http.Handler) http.Handler {
 // handleCreateGame handles the creation of a new game.
 func (s *apiServer) handleCreateGame() http.HandlerFunc {
 	type request struct {
-		Rows       int   `json:"rows"`
-		Cols       int   `json:"cols"`
-		NumPlayers int   `json:"num_players"`
-		PlayerId   int   `json:"player_id"`
-		BotLevels  []int `json:"bot_levels"`
+		Rows       int               `json:"rows"`
+		Cols       int               `json:"cols"`
+		NumPlayers int               `json:"num_players"`
+		PlayerId   int               `json:"player_id"`
+		BotLevels  []botRef          `json:"bot_levels"`
+		Players    []game.PlayerInfo `json:"players"`
+		Handicaps  []game.Handicap   `json:"handicaps"`
+		Seed       int64             `json:"seed"` // Seed of the board and the bots, random if 0
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -134,7 +178,7 @@ func (s *apiServer) handleCreateGame() http.HandlerFunc {
 			return
 		}
 
-		g, err := game.NewGame(req.Rows, req.Cols, req.NumPlayers, 0)
+		g, err := game.NewGame(req.Rows, req.Cols, req.NumPlayers, req.Seed)
 
 		if err != nil {
 			s.logger.WithError(err).Error("Error creating new game")
@@ -142,12 +186,29 @@ func (s *apiServer) handleCreateGame() http.HandlerFunc {
 			return
 		}
 
+		if err := setupPlayers(g, req.PlayerId, req.BotLevels, req.Players); err != nil {
+			s.logger.WithError(err).Error("Error setting up players")
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := setupHandicaps(g, req.Handicaps); err != nil {
+			s.logger.WithError(err).Error("Error setting up handicaps")
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
 		user := r.Context().Value(ctxKeyUser).(*User)
-		user.createGame(g, req.PlayerId, req.BotLevels)
+		if err := user.createGame(g, req.PlayerId, s.bots); err != nil {
+			s.logger.WithError(err).Error("Error creating bots")
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
 
 		s.logger.WithFields(logrus.Fields{
 			"cols": g.Board.Cols(),
 			"rows": g.Board.Rows(),
+			"seed": g.Seed(),
 		}).Info("New game")
 		s.respond(w, r, http.StatusCreated, g.ToMap())
 	}
@@ -239,6 +300,47 @@ func (s *apiServer) handleMakeUpgrade() http.HandlerFunc {
 	}
 }
 
+// handleHint handles retrieving the next move suggested to the user.
+func (s *apiServer) handleHint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(ctxKeyUser).(*User)
+
+		ctx := r.Context()
+		if s.botBudget.Move > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.botBudget.Move)
+			defer cancel()
+		}
+
+		hint, err := user.hint(ctx, s.hintBot)
+		if err != nil {
+			s.logger.WithError(err).Error("Error suggesting move")
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.logger.WithField("reason", hint.Reason).Info("Hint retrieved")
+		s.respond(w, r, http.StatusOK, hint)
+	}
+}
+
+// handleUpgradeHint handles retrieving the suggested upgrades of the user.
+func (s *apiServer) handleUpgradeHint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(ctxKeyUser).(*User)
+		upgrades, err := user.upgradeHint()
+
+		if err != nil {
+			s.logger.WithError(err).Error("Error planning upgrades")
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.logger.Info("Upgrade hint retrieved")
+		s.respond(w, r, http.StatusOK, upgrades)
+	}
+}
+
 // handleEndTurn handles ending the current turn.
 func (s *apiServer) handleEndTurn() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -251,7 +353,7 @@ func (s *apiServer) handleEndTurn() http.HandlerFunc {
 			return
 		}
 
-		err = doAllBotsTurns(user.GameBox.Game, user.GameBox.UserId, user.GameBox.difficulties)
+		err = doAllBotsTurns(context.WithoutCancel(r.Context()), user.GameBox, s.botBudget, s.botRest)
 		if err != nil {
 			s.logger.WithError(err).Error("Error bot turns")
 		}
@@ -261,6 +363,101 @@ func (s *apiServer) handleEndTurn() http.HandlerFunc {
 	}
 }
 
+// handlePass handles skipping the whole turn.
+func (s *apiServer) handlePass() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(ctxKeyUser).(*User)
+		err := user.pass()
+
+		if err != nil {
+			s.logger.WithError(err).Error("Error passing turn")
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		err = doAllBotsTurns(context.WithoutCancel(r.Context()), user.GameBox, s.botBudget, s.botRest)
+		if err != nil {
+			s.logger.WithError(err).Error("Error bot turns")
+		}
+
+		s.logger.Info("Turn passed")
+		s.respond(w, r, http.StatusOK, user.GameBox.Game.ToMap())
+	}
+}
+
+// handleResign handles the user leaving the game.
+func (s *apiServer) handleResign() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(ctxKeyUser).(*User)
+		err := user.resign()
+
+		if err != nil {
+			s.logger.WithError(err).Error("Error resigning")
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		err = doAllBotsTurns(context.WithoutCancel(r.Context()), user.GameBox, s.botBudget, s.botRest)
+		if err != nil {
+			s.logger.WithError(err).Error("Error bot turns")
+		}
+
+		s.logger.Info("User resigned")
+		s.respond(w, r, http.StatusOK, user.GameBox.Game.ToMap())
+	}
+}
+
+// handleOfferDraw handles offering a draw.
+func (s *apiServer) handleOfferDraw() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(ctxKeyUser).(*User)
+		err := user.offerDraw()
+
+		if err != nil {
+			s.logger.WithError(err).Error("Error offering draw")
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.logger.Info("Draw offered")
+		s.respond(w, r, http.StatusOK, user.GameBox.Game.ToMap())
+	}
+}
+
+// handleAcceptDraw handles accepting a draw offer.
+func (s *apiServer) handleAcceptDraw() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(ctxKeyUser).(*User)
+		err := user.acceptDraw()
+
+		if err != nil {
+			s.logger.WithError(err).Error("Error accepting draw")
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.logger.Info("Draw accepted")
+		s.respond(w, r, http.StatusOK, user.GameBox.Game.ToMap())
+	}
+}
+
+// handleDeclineDraw handles declining a draw offer.
+func (s *apiServer) handleDeclineDraw() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(ctxKeyUser).(*User)
+		err := user.declineDraw()
+
+		if err != nil {
+			s.logger.WithError(err).Error("Error declining draw")
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.logger.Info("Draw declined")
+		s.respond(w, r, http.StatusOK, user.GameBox.Game.ToMap())
+	}
+}
+
 // handleGetMap handles retrieving the game map.
 func (s *apiServer) handleGetMap() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -277,6 +474,30 @@ func (s *apiServer) handleGetMap() http.HandlerFunc {
 	}
 }
 
+// handleBotTrace handles retrieving the decisions of the bots in the user's game.
+func (s *apiServer) handleBotTrace() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(ctxKeyUser).(*User)
+		decisions, err := user.botTrace()
+
+		if err != nil {
+			s.logger.WithError(err).Error("Error retrieving bot trace")
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.logger.Info("Retrieved bot trace")
+		s.respond(w, r, http.StatusOK, decisions)
+	}
+}
+
+// handleListBots handles retrieving the available bots.
+func (s *apiServer) handleListBots() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respond(w, r, http.StatusOK, bot.List())
+	}
+}
+
 // CreateFullGame is an endpoint imitation for tests.
 func (s *apiServer) CreateFullGame() http.HandlerFunc {
 	type request struct {
@@ -306,32 +527,64 @@ func (s *apiServer) CreateFullGame() http.HandlerFunc {
 			return
 		}
 
+		if err := setupPlayers(g, req.PlayerId, nil, nil); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
 		user := r.Context().Value(ctxKeyUser).(*User)
-		user.createGame(g, req.PlayerId, []int{})
+		if err := user.createGame(g, req.PlayerId, s.bots); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
 
 		s.respond(w, r, http.StatusCreated, g.ToMap())
 	}
 }
 
-// doAllBotsTurns performs the turns for all AI players.
-func doAllBotsTurns(g *game.Game, playerId int, difficulties []int) error {
+// doAllBotsTurns performs the turns for all AI players
+// until the turn passes to a human. If the humans are out of the game,
+// the bots play until the game is finished. Every turn is limited by the budget,
+// and the turns after the humans are out are limited by the rest budget all together,
+// so that the user's requests are not blocked for long. The bots out of time end their turns without moves.
+// The decisions of the bots are recorded to the trace of the game, if it has one,
+// and the bots chosen by the adaptive bots are shown in the game state.
+func doAllBotsTurns(ctx context.Context, box gameBox, budget bot.Budget, rest time.Duration) error {
+	g, bots := box.Game, box.bots
+	if box.trace != nil {
+		ctx = bot.WithTrace(ctx, box.trace)
+	}
+
 	var err error
+	limited := false
+
+	for !g.IsFinished() {
+		player := g.Players[g.Turn()]
 
-	for g.Turn() != playerId && g.Players[playerId].CellsCount() != 0 && !g.IsFinished() {
-		if g.Turn() >= len(difficulties) {
-			return errIncorrectDifficultiesLen
+		if !limited && rest > 0 && box.humansOut() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, rest)
+			defer cancel()
+			limited = true
 		}
 
-		attackErr := bot.DoAttack(g, g.Players[g.Turn()], difficulties[g.Turn()])
-		if attackErr != nil {
-			err = attackErr
+		strategy, ok := bots[player.Id()]
+		if !ok {
+			break
 		}
 
-		upgradeErr := bot.DoUpgrade(g, g.Players[g.Turn()], difficulties[g.Turn()])
-		if upgradeErr != nil && err == nil {
-			err = upgradeErr
+		turnErr := bot.PlayTurn(ctx, g, player, strategy, budget)
+		if turnErr != nil && err == nil {
+			err = turnErr
+		}
+		if adaptive, ok := strategy.(*bot.Adaptive); ok {
+			setBotPlaying(g, player.Id(), adaptive.Playing())
 		}
 	}
+
+	if g.IsFinished() {
+		closeBots(bots)
+	}
 	return err
 }
 
@@ -2,12 +2,21 @@ package apiserver
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/Vacym/neighbors-force/internal/bot"
 	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/Vacym/neighbors-force/internal/neural"
+	"github.com/Vacym/neighbors-force/internal/proxyserver"
 	"github.com/gorilla/sessions"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -122,6 +131,173 @@ func TestServer_handleGameCreate(t *testing.T) {
 			},
 			expectedCode: http.StatusUnprocessableEntity,
 		},
+		{
+			name: "named players",
+			payload: map[string]any{
+				"rows":        3,
+				"cols":        3,
+				"num_players": 2,
+				"players": []map[string]any{
+					{"name": "Alice", "color": "#FFFFFF"},
+					{"name": "Bob", "controller": map[string]any{"kind": "bot", "level": 1}},
+				},
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "user seat controlled by bot",
+			payload: map[string]any{
+				"rows":        3,
+				"cols":        3,
+				"num_players": 2,
+				"players": []map[string]any{
+					{"controller": map[string]any{"kind": "bot"}},
+				},
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "second human seat",
+			payload: map[string]any{
+				"rows":        3,
+				"cols":        3,
+				"num_players": 2,
+				"players": []map[string]any{
+					{},
+					{"controller": map[string]any{"kind": "human"}},
+				},
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "unknown controller",
+			payload: map[string]any{
+				"rows":        3,
+				"cols":        3,
+				"num_players": 2,
+				"players": []map[string]any{
+					{},
+					{"controller": map[string]any{"kind": "alien"}},
+				},
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "external bot at the default endpoint",
+			payload: map[string]any{
+				"rows":        3,
+				"cols":        3,
+				"num_players": 2,
+				"players": []map[string]any{
+					{},
+					{"controller": map[string]any{"kind": "external"}},
+				},
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "external bot at a forbidden endpoint",
+			payload: map[string]any{
+				"rows":        3,
+				"cols":        3,
+				"num_players": 2,
+				"players": []map[string]any{
+					{},
+					{"controller": map[string]any{"kind": "external", "endpoint": "http://10.0.0.1:80"}},
+				},
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "external bot at a malformed endpoint",
+			payload: map[string]any{
+				"rows":        3,
+				"cols":        3,
+				"num_players": 2,
+				"players": []map[string]any{
+					{},
+					{"controller": map[string]any{"kind": "external", "endpoint": "file:///etc/passwd"}},
+				},
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "too many players described",
+			payload: map[string]any{
+				"rows":        3,
+				"cols":        3,
+				"num_players": 2,
+				"players":     []map[string]any{{}, {}, {}},
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "bot names and levels",
+			payload: map[string]any{
+				"rows":        5,
+				"cols":        5,
+				"num_players": 3,
+				"bot_levels":  []any{0, "greedy", 0},
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "bot profiles",
+			payload: map[string]any{
+				"rows":        5,
+				"cols":        5,
+				"num_players": 3,
+				"players": []map[string]any{
+					{},
+					{"controller": map[string]any{"kind": "bot", "bot": "turtle"}},
+					{"controller": map[string]any{"kind": "bot", "bot": "rusher"}},
+				},
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "unknown bot name",
+			payload: map[string]any{
+				"rows":        5,
+				"cols":        5,
+				"num_players": 2,
+				"bot_levels":  []any{0, "unknown"},
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "unknown bot level",
+			payload: map[string]any{
+				"rows":        5,
+				"cols":        5,
+				"num_players": 2,
+				"bot_levels":  []any{0, 100},
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "handicaps",
+			payload: map[string]any{
+				"rows":        5,
+				"cols":        5,
+				"num_players": 2,
+				"handicaps": []map[string]any{
+					{},
+					{"points": 5, "cells": 2, "level": 1, "income": 1.5},
+				},
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "negative handicap",
+			payload: map[string]any{
+				"rows":        5,
+				"cols":        5,
+				"num_players": 2,
+				"handicaps":   []map[string]any{{"points": -5}},
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
 		{
 			name: "user_id is excessive",
 			payload: map[string]int{
@@ -149,11 +325,176 @@ func TestServer_handleGameCreate(t *testing.T) {
 	}
 }
 
+func TestServer_handleGameCreate_seed(t *testing.T) {
+	// playTurn creates the seeded game against the random and the mcts bots and plays a turn of the user
+	playTurn := func() map[string]any {
+		s := newTestServer()
+
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]any{
+			"rows":        7,
+			"cols":        7,
+			"num_players": 3,
+			"bot_levels":  []int{0, 0, 3},
+			"seed":        42,
+		})
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/game/create", b)
+		s.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		cookies := rec.Result().Cookies()
+		require.Equal(t, http.StatusOK, postWithCookies(s, "/game/end_attack", cookies).Code)
+		rec = postWithCookies(s, "/game/end_turn", cookies)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var gameMap map[string]any
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&gameMap))
+		return gameMap
+	}
+
+	first := playTurn()
+	assert.Equal(t, float64(42), first["seed"])
+	assert.Equal(t, first, playTurn())
+}
+
+func TestServer_handleGameCreate_adaptive(t *testing.T) {
+	s := newTestServer()
+
+	// create starts the game against the adaptive bot in the session and returns the bot it plays as
+	create := func(cookies []*http.Cookie) (string, []*http.Cookie) {
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]any{
+			"rows":        7,
+			"cols":        7,
+			"num_players": 2,
+			"bot_levels":  []any{0, "adaptive"},
+			"seed":        42,
+		})
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/game/create", b)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		s.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		var gameMap map[string]any
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&gameMap))
+		controller := gameMap["players"].([]any)[1].(map[string]any)["controller"].(map[string]any)
+		assert.Equal(t, "adaptive", controller["bot"])
+		assert.Equal(t, float64(0), controller["level"])
+		if cookies == nil {
+			cookies = rec.Result().Cookies()
+		}
+		return controller["playing"].(string), cookies
+	}
+
+	playing, cookies := create(nil)
+	assert.Equal(t, "greedy", playing)
+
+	// After a lost game the next one starts with a weaker bot
+	require.Equal(t, http.StatusOK, postWithCookies(s, "/game/resign", cookies).Code)
+	playing, _ = create(cookies)
+	assert.Equal(t, "random", playing)
+}
+
+func TestServer_concurrentRequests(t *testing.T) {
+	s := newTestServer()
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]any{
+		"rows":        7,
+		"cols":        7,
+		"num_players": 2,
+		"bot_levels":  []int{0, 1},
+		"seed":        42,
+	})
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/game/create", b)
+	s.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	cookies := rec.Result().Cookies()
+
+	// A double-clicked attack races the end of the turn and the polling of the map,
+	// while new sessions are registered
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			attack := &bytes.Buffer{}
+			json.NewEncoder(attack).Encode(makeAttackValidPayload)
+			requests := []*http.Request{
+				httptest.NewRequest(http.MethodPost, "/game/attack", attack),
+				httptest.NewRequest(http.MethodPost, "/game/end_turn", nil),
+				httptest.NewRequest(http.MethodGet, "/game/get_map", nil),
+				httptest.NewRequest(http.MethodGet, "/game/upgrade_hint", nil),
+			}
+			for _, req := range requests {
+				for _, c := range cookies {
+					req.AddCookie(c)
+				}
+				rec := httptest.NewRecorder()
+				s.ServeHTTP(rec, req)
+				assert.Contains(t, []int{http.StatusOK, http.StatusUnprocessableEntity}, rec.Code)
+			}
+
+			// Without cookies every request starts a new session
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/bots", nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, http.StatusOK, getWithCookies(s, "/game/get_map", cookies).Code)
+	assert.Equal(t, 21, s.users.count())
+
+	// The moves were made one at a time
+	for _, user := range s.users.users {
+		if user.GameBox.Game != nil {
+			assert.NoError(t, user.GameBox.Game.CheckInvariants())
+		}
+	}
+}
+
 var makeAttackValidPayload = map[string]game.Coords{
 	"from": {Row: 0, Col: 0},
 	"to":   {Row: 0, Col: 1},
 }
 
+// closeCounter is a bot that counts the times it is closed.
+type closeCounter struct {
+	bot.Strategy
+	closed int
+}
+
+// Close counts the call.
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestUserRegistry_evictIdle(t *testing.T) {
+	users := newUserRegistry()
+
+	idle, _ := users.get("idle")
+	strategy := &closeCounter{}
+	idle.GameBox.bots = map[int]bot.Strategy{1: strategy}
+	idle.lastSeen = time.Now().Add(-time.Hour)
+	users.get("active")
+
+	assert.Equal(t, 1, users.evictIdle(time.Minute))
+	assert.Equal(t, 1, users.count())
+	assert.Equal(t, 1, strategy.closed)
+
+	// The evicted user starts over
+	_, created := users.get("idle")
+	assert.True(t, created)
+}
+
 func TestServer_handleMakeAttack(t *testing.T) {
 	s := newTestServer()
 
@@ -437,6 +778,50 @@ func TestServer_handleEndTurn(t *testing.T) {
 	})
 }
 
+func TestServer_hotSeat(t *testing.T) {
+	s := newTestServer()
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]any{
+		"rows":        5,
+		"cols":        5,
+		"num_players": 3,
+		"players": []map[string]any{
+			{"name": "Alice"},
+			{"name": "Bob", "controller": map[string]any{"kind": "human"}},
+			{"controller": map[string]any{"kind": "bot", "bot": "greedy"}},
+		},
+	})
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/game/create", b)
+	s.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	cookies := rec.Result().Cookies()
+
+	// The first human seat offers a draw, the bot does not lead and agrees
+	assert.Equal(t, http.StatusOK, postWithCookies(s, "/game/offer_draw", cookies).Code)
+
+	// The turn passes to the second human seat, which the user plays too
+	var gameMap map[string]any
+	rec = postWithCookies(s, "/game/end_turn", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&gameMap))
+	assert.Equal(t, float64(1), gameMap["turn"])
+
+	// The bot moves after the second human seat
+	rec = postWithCookies(s, "/game/end_turn", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&gameMap))
+	assert.Equal(t, float64(0), gameMap["turn"])
+
+	// The second human seat agrees to the draw in its turn
+	require.Equal(t, http.StatusOK, postWithCookies(s, "/game/end_turn", cookies).Code)
+	rec = postWithCookies(s, "/game/accept_draw", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&gameMap))
+	assert.Equal(t, true, gameMap["draw"])
+}
+
 func TestServer_handleGetMap(t *testing.T) {
 	s := newTestServer()
 
@@ -492,3 +877,313 @@ func TestServer_handleGetMap(t *testing.T) {
 		})
 	}
 }
+
+// createTestGame creates a full board game and returns the session cookies.
+func createTestGame(t *testing.T, s *apiServer) []*http.Cookie {
+	createGameRec := httptest.NewRecorder()
+	gameBuf := &bytes.Buffer{}
+	json.NewEncoder(gameBuf).Encode(gameCreateValidPayload)
+	createGameReq, _ := http.NewRequest(http.MethodPost, "/test/create_full", gameBuf)
+
+	s.ServeTestHTTP(createGameRec, createGameReq)
+	require.Equal(t, http.StatusCreated, createGameRec.Code)
+
+	return createGameRec.Result().Cookies()
+}
+
+// postWithCookies sends an empty POST request on behalf of the session.
+func postWithCookies(s *apiServer, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, path, nil)
+
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+// getWithCookies sends a GET request on behalf of the session.
+func getWithCookies(s *apiServer, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_handleHint(t *testing.T) {
+	s := newTestServer()
+
+	cookies := createTestGame(t, s)
+	rec := getWithCookies(s, "/game/hint", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var hint bot.Hint
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&hint))
+	assert.NotEmpty(t, hint.Reason)
+	assert.Nil(t, hint.Upgrade)
+
+	// The hint does not make the move
+	rec = getWithCookies(s, "/game/hint", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var again bot.Hint
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&again))
+	assert.Equal(t, hint, again)
+
+	// test without creating game
+	t.Run("game is not exist", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnprocessableEntity, getWithCookies(s, "/game/hint", nil).Code)
+	})
+}
+
+func TestServer_handleBotTrace(t *testing.T) {
+	s := newTestServer()
+	s.bots.trace = true
+
+	cookies := createTestGame(t, s)
+	rec := getWithCookies(s, "/game/bot_trace", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, "[]", rec.Body.String())
+
+	// The bot makes its turn after the user's one
+	require.Equal(t, http.StatusOK, postWithCookies(s, "/game/end_attack", cookies).Code)
+	require.Equal(t, http.StatusOK, postWithCookies(s, "/game/end_turn", cookies).Code)
+
+	rec = getWithCookies(s, "/game/bot_trace", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var decisions []bot.Decision
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&decisions))
+	require.NotEmpty(t, decisions)
+	assert.Equal(t, 1, decisions[0].Player)
+
+	t.Run("trace is disabled", func(t *testing.T) {
+		s := newTestServer()
+		cookies := createTestGame(t, s)
+		assert.Equal(t, http.StatusUnprocessableEntity, getWithCookies(s, "/game/bot_trace", cookies).Code)
+	})
+
+	// test without creating game
+	t.Run("game is not exist", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnprocessableEntity, getWithCookies(s, "/game/bot_trace", nil).Code)
+	})
+}
+
+func TestServer_handleUpgradeHint(t *testing.T) {
+	s := newTestServer()
+
+	cookies := createTestGame(t, s)
+	rec := getWithCookies(s, "/game/upgrade_hint", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var upgrades []bot.Upgrade
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&upgrades))
+	assert.NotNil(t, upgrades)
+
+	// test without creating game
+	t.Run("game is not exist", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnprocessableEntity, getWithCookies(s, "/game/upgrade_hint", nil).Code)
+	})
+}
+
+func TestServer_handlePass(t *testing.T) {
+	s := newTestServer()
+
+	cookies := createTestGame(t, s)
+	assert.Equal(t, http.StatusOK, postWithCookies(s, "/game/pass", cookies).Code)
+
+	// Passing is not allowed after the attack phase
+	require.Equal(t, http.StatusOK, postWithCookies(s, "/game/end_attack", cookies).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, postWithCookies(s, "/game/pass", cookies).Code)
+
+	// test without creating game
+	t.Run("game is not exist", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnprocessableEntity, postWithCookies(s, "/game/pass", nil).Code)
+	})
+}
+
+func TestServer_handleResign(t *testing.T) {
+	s := newTestServer()
+
+	cookies := createTestGame(t, s)
+	rec := postWithCookies(s, "/game/resign", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var gameMap map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&gameMap))
+	assert.Equal(t, float64(1), gameMap["winner_id"])
+
+	// The game is already finished
+	assert.Equal(t, http.StatusUnprocessableEntity, postWithCookies(s, "/game/resign", cookies).Code)
+
+	// test without creating game
+	t.Run("game is not exist", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnprocessableEntity, postWithCookies(s, "/game/resign", nil).Code)
+	})
+}
+
+func TestServer_handleResign_restBudget(t *testing.T) {
+	s := newTestServer()
+	s.botRest = 100 * time.Millisecond
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]any{
+		"rows":        7,
+		"cols":        7,
+		"num_players": 3,
+		"bot_levels":  []any{0, "mcts", "mcts"},
+		"seed":        42,
+	})
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/game/create", b)
+	s.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	// The bots finish the game without thinking after the rest budget runs out
+	start := time.Now()
+	rec = postWithCookies(s, "/game/resign", rec.Result().Cookies())
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Less(t, time.Since(start), s.botBudget.Turn)
+
+	for _, user := range s.users.users {
+		assert.True(t, user.GameBox.Game.IsFinished())
+	}
+}
+
+func TestServer_handleDraw(t *testing.T) {
+	s := newTestServer()
+
+	cookies := createTestGame(t, s)
+
+	// Nobody offered a draw
+	assert.Equal(t, http.StatusUnprocessableEntity, postWithCookies(s, "/game/accept_draw", cookies).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, postWithCookies(s, "/game/decline_draw", cookies).Code)
+
+	// The bot leads and declines the draw
+	for _, user := range s.users.users {
+		require.NoError(t, user.GameBox.Game.SetHandicap(1, game.Handicap{Cells: 2}))
+	}
+	rec := postWithCookies(s, "/game/offer_draw", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var gameMap map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&gameMap))
+	assert.Equal(t, false, gameMap["draw"])
+	assert.Empty(t, gameMap["draw_offers"])
+	assert.Equal(t, http.StatusUnprocessableEntity, postWithCookies(s, "/game/accept_draw", cookies).Code)
+
+	// The bot does not lead and accepts the draw
+	cookies = createTestGame(t, s)
+	rec = postWithCookies(s, "/game/offer_draw", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&gameMap))
+	assert.Equal(t, true, gameMap["draw"])
+	assert.Equal(t, http.StatusUnprocessableEntity, postWithCookies(s, "/game/offer_draw", cookies).Code)
+
+	// test without creating game
+	t.Run("game is not exist", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnprocessableEntity, postWithCookies(s, "/game/offer_draw", nil).Code)
+	})
+}
+
+func TestRegisterProfiles(t *testing.T) {
+	require.NoError(t, registerProfiles("../../configs/profiles-example.toml"))
+
+	_, err := bot.New("sniper")
+	assert.NoError(t, err)
+
+	// Names are taken
+	assert.Error(t, registerProfiles("../../configs/profiles-example.toml"))
+
+	// Missing file
+	assert.Error(t, registerProfiles("missing.toml"))
+}
+
+func TestConfigureMCTS(t *testing.T) {
+	t.Cleanup(func() { bot.ConfigureMCTS() })
+
+	config := proxyserver.NewConfig()
+	config.MCTSIterations = 10
+	config.MCTSPlayout = "random"
+	assert.NoError(t, configureMCTS(config))
+
+	// Unknown playout bot
+	config.MCTSPlayout = "unknown"
+	assert.Error(t, configureMCTS(config))
+}
+
+func TestRegisterNeural(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "network.json")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, neural.NewNetwork(5, 5, []int{4}, 1).Save(file))
+	require.NoError(t, file.Close())
+
+	require.NoError(t, registerNeural(""))
+	require.NoError(t, registerNeural(path))
+	_, err = bot.New("neural")
+	assert.NoError(t, err)
+
+	// Name is taken
+	assert.Error(t, registerNeural(path))
+}
+
+func TestOpeningBook_wrap(t *testing.T) {
+	strategy, err := bot.New("greedy")
+	require.NoError(t, err)
+
+	assert.Equal(t, strategy, openingBook{}.wrap(3, strategy))
+
+	book := openingBook{book: bot.NewBook(), level: 2, minPlays: 1}
+	assert.Equal(t, strategy, book.wrap(1, strategy))
+	assert.NotEqual(t, strategy, book.wrap(2, strategy))
+
+	// The bot server plays its own moves in the opening
+	setup := newTestServer().bots
+	setup.book = openingBook{book: bot.NewBook(), level: 0, minPlays: 1}
+	strategy, err = setup.newStrategy(game.Controller{Kind: game.ControllerBot, Level: 2})
+	require.NoError(t, err)
+	assert.IsType(t, bot.NewAPI(), strategy)
+
+	strategy, err = setup.newStrategy(game.Controller{Kind: game.ControllerBot, Level: 3})
+	require.NoError(t, err)
+	assert.NotEqual(t, reflect.TypeOf(bot.NewMCTS()), reflect.TypeOf(strategy))
+}
+
+func TestBotSetup_newStrategy_api(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	g, err := game.NewGame(5, 5, 2, 1)
+	require.NoError(t, err)
+
+	setup := botSetup{external: externalBots{
+		endpoints: []string{server.URL},
+		options:   []bot.APIOption{bot.WithRetries(0), bot.WithFallback("")},
+	}}
+	controllers := []game.Controller{
+		{Kind: game.ControllerBot, Level: 2},
+		{Kind: game.ControllerBot, Bot: bot.APIName},
+	}
+	for _, c := range controllers {
+		strategy, err := setup.newStrategy(c)
+		require.NoError(t, err)
+
+		// The configured client asks the configured bot server
+		_, err = strategy.PlanAttack(context.Background(), g, g.Players[0])
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 2, requests)
+
+	// No bot server is allowed
+	_, err = botSetup{}.newStrategy(controllers[0])
+	assert.ErrorIs(t, err, errForbiddenEndpoint)
+}
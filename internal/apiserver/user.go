@@ -1,27 +1,70 @@
 package apiserver
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
 
+	"github.com/Vacym/neighbors-force/internal/bot"
 	"github.com/Vacym/neighbors-force/internal/game"
 )
 
 var (
-	errGameIsNotExist           = errors.New("game has not been created yet")
-	errIndexOutOfRange          = errors.New("index out of range")
-	errIncorrectDifficultiesLen = errors.New("incorrect slice of difficulties")
+	errGameIsNotExist     = errors.New("game has not been created yet")
+	errIndexOutOfRange    = errors.New("index out of range")
+	errIncorrectUserSeat  = errors.New("user's seat must be controlled by a human")
+	errIncorrectPlayerLen = errors.New("more player descriptions than players")
+	errIncorrectHandicaps = errors.New("more handicaps than players")
+	errForbiddenEndpoint  = errors.New("endpoint of the bot server is not allowed")
+	errTraceDisabled      = errors.New("bot decisions are not traced")
 )
 
 // gameBox holds a reference to the current game and the user's ID.
 type gameBox struct {
-	Game         *game.Game
-	UserId       int
-	difficulties []int
+	Game   *game.Game
+	UserId int
+	bots   map[int]bot.Strategy // Strategies of the bot players by their IDs
+	trace  *bot.Trace           // Decisions of the bots, nil if they are not traced
 }
 
+// humansOut reports whether every human seat of the game has resigned or lost all its cells.
+func (b gameBox) humansOut() bool {
+	for _, player := range b.Game.Players {
+		if _, isBot := b.bots[player.Id()]; !isBot && !player.Resigned() && player.CellsCount() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// botRef selects a built-in bot either by its level or by its name.
+type botRef struct {
+	Level int
+	Name  string
+}
+
+// UnmarshalJSON accepts either a bot level number or a bot name string.
+func (b *botRef) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &b.Name); err == nil {
+		return nil
+	}
+	return json.Unmarshal(data, &b.Level)
+}
+
+// resultSkillStep is the change of the skill of the adaptive bots after a game the user has won or lost.
+const resultSkillStep = 0.5
+
 // User represents a user and their actions in the game.
 type User struct {
-	GameBox gameBox
+	mu         sync.Mutex // Serializes the requests of the user, held by UserMiddleware
+	GameBox    gameBox
+	skill      float64   // Skill the adaptive bots of the next game start at
+	skillKnown bool      // Whether the skill is learned from the games of the session
+	lastSeen   time.Time // Time of the last request of the user, guarded by the registry
 }
 
 // NewUser creates a new User instance.
@@ -29,22 +72,295 @@ func NewUser() *User {
 	return &User{}
 }
 
-// me returns the current user's player instance.
+// userRegistry holds the active users by their session IDs. It is safe for concurrent use.
+type userRegistry struct {
+	mu    sync.Mutex
+	users map[string]*User
+}
+
+// newUserRegistry creates an empty registry.
+func newUserRegistry() *userRegistry {
+	return &userRegistry{users: make(map[string]*User)}
+}
+
+// get returns the user with the ID, creating the user if there is none,
+// and marks the user as seen. It reports whether the user has been created.
+func (r *userRegistry) get(id string) (*User, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		user = NewUser()
+		r.users[id] = user
+	}
+	user.lastSeen = time.Now()
+	return user, !ok
+}
+
+// evictIdle removes the users not seen for longer than the timeout and stops the bots of their games,
+// e.g. the engine processes. It returns the count of the removed users.
+func (r *userRegistry) evictIdle(timeout time.Duration) int {
+	cutoff := time.Now().Add(-timeout)
+
+	r.mu.Lock()
+	var idle []*User
+	for id, user := range r.users {
+		if user.lastSeen.Before(cutoff) {
+			idle = append(idle, user)
+			delete(r.users, id)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, user := range idle {
+		user.mu.Lock()
+		closeBots(user.GameBox.bots)
+		user.GameBox.bots = nil
+		user.mu.Unlock()
+	}
+	return len(idle)
+}
+
+// count returns the count of the active users.
+func (r *userRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.users)
+}
+
+// me returns the player the user acts for. The user owns every human seat of the game,
+// so in a hot-seat game it is the human seat to move, otherwise it is the user's seat.
 func (u *User) me() game.Player {
-	return u.GameBox.Game.Players[u.GameBox.UserId]
+	g := u.GameBox.Game
+	if _, isBot := u.GameBox.bots[g.Turn()]; !isBot && !g.IsFinished() {
+		return g.Players[g.Turn()]
+	}
+	return g.Players[u.GameBox.UserId]
 }
 
 // createGame sets the current game and user's ID in the user's GameBox.
-func (u *User) createGame(g *game.Game, id int, difficulties []int) {
+// The bots are seeded from the game, so that the game can be replayed.
+// It fails if any player is controlled by an unknown bot.
+// The adaptive bots track the user and start at the skill learned from the previous games of the session.
+func (u *User) createGame(g *game.Game, id int, setup botSetup) error {
+	bots := make(map[int]bot.Strategy)
+	for _, player := range g.Players {
+		strategy, err := setup.newStrategy(player.Controller())
+		if err != nil {
+			return err
+		}
+		if strategy != nil {
+			bot.Seed(strategy, g.Seed(), player.Id())
+			bots[player.Id()] = strategy
+		}
+	}
+
+	u.rateLastGame()
+	skill := setup.adaptiveSkill
+	if u.skillKnown {
+		skill = u.skill
+	}
+	for playerId, strategy := range bots {
+		if adaptive, ok := strategy.(*bot.Adaptive); ok {
+			adaptive.Start(id, skill)
+			setBotPlaying(g, playerId, adaptive.Playing())
+		}
+	}
+
+	closeBots(u.GameBox.bots)
 	u.GameBox.Game = g
 	u.GameBox.UserId = id
+	u.GameBox.bots = bots
+	u.GameBox.trace = nil
+	if setup.trace {
+		u.GameBox.trace = bot.NewTrace()
+	}
+	return nil
+}
+
+// rateLastGame learns the skill of the adaptive bots from the finished game of the user:
+// the skill they have reached in the game, raised if the user has won and lowered if the user has lost.
+func (u *User) rateLastGame() {
+	g := u.GameBox.Game
+	if g == nil || !g.IsFinished() {
+		return
+	}
 
-	// Ensure that the difficulties slice has the same length as the number of players in the game.
-	difficultyCount := len(g.Players)
-	if len(difficulties) < difficultyCount {
-		difficulties = append(difficulties, make([]int, difficultyCount-len(difficulties))...)
+	total, count := 0.0, 0
+	for _, strategy := range u.GameBox.bots {
+		if adaptive, ok := strategy.(*bot.Adaptive); ok {
+			total += adaptive.Skill
+			count++
+		}
 	}
-	u.GameBox.difficulties = difficulties[:difficultyCount]
+	if count == 0 {
+		return
+	}
+
+	result := 0.5
+	if winner := g.Winner(); winner != nil {
+		result = 0
+		if winner.Id() == u.GameBox.UserId {
+			result = 1
+		}
+	}
+
+	skill := total/float64(count) + resultSkillStep*(2*result-1)
+	u.skill = math.Max(0, math.Min(bot.MaxSkill(), skill))
+	u.skillKnown = true
+}
+
+// setBotPlaying shows the bot the adaptive bot of the player plays as in the game state.
+func setBotPlaying(g *game.Game, id int, name string) {
+	player := g.Players[id]
+	controller := player.Controller()
+	controller.Playing = name
+	g.SetPlayerInfo(id, game.PlayerInfo{Name: player.Name(), Color: player.Color(), Controller: controller})
+}
+
+// closeBots releases the resources of the bots, e.g. stops the engine processes.
+func closeBots(bots map[int]bot.Strategy) {
+	for _, strategy := range bots {
+		if closer, ok := strategy.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// botSetup configures the bots of new games.
+type botSetup struct {
+	external externalBots // Clients of the external bot servers
+	trace    bool         // Record the decisions of the bots
+	book     openingBook  // Opening book of the stronger built-in bots
+
+	adaptiveSkill float64 // Skill the adaptive bots start at in the first game of a session
+}
+
+// newStrategy creates the bot that makes moves for the controller.
+// It returns nil if the moves are made by a human.
+// The "api" bot plays with the configured client of the default bot server.
+func (s botSetup) newStrategy(c game.Controller) (bot.Strategy, error) {
+	switch c.Kind {
+	case game.ControllerBot:
+		var info bot.Info
+		var err error
+		if c.Bot == "" {
+			info, err = bot.LookupLevel(c.Level)
+		} else {
+			info, err = bot.Lookup(c.Bot)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if info.Name == bot.APIName {
+			return s.external.newStrategy("")
+		}
+
+		strategy, err := bot.New(info.Name)
+		if err != nil {
+			return nil, err
+		}
+		return s.book.wrap(info.Level, strategy), nil
+	case game.ControllerExternal:
+		return s.external.newStrategy(c.Endpoint)
+	}
+	return nil, nil
+}
+
+// openingBook makes the built-in bots of the higher levels play the moves of the book in the openings.
+type openingBook struct {
+	book     *bot.Book // Book of the moves, nil for none
+	level    int       // Min level of the bots that consult the book
+	minPlays int       // Plays of a move needed to trust it
+}
+
+// wrap returns the strategy that consults the book, if the level of the bot is high enough.
+// Only the built-in bots searching their own moves are wrapped, the "api" bot is never passed here.
+func (b openingBook) wrap(level int, strategy bot.Strategy) bot.Strategy {
+	if b.book == nil || level < b.level {
+		return strategy
+	}
+	return bot.WithBook(strategy, b.book, b.minPlays)
+}
+
+// externalBots configures the clients of the external bot servers.
+type externalBots struct {
+	endpoints []string        // Endpoints the seats may use, the first one is the default
+	options   []bot.APIOption // Options of every client
+}
+
+// newStrategy creates the client of the bot server at the endpoint.
+// An empty endpoint selects the default one. Only the configured endpoints are allowed,
+// so that users cannot make the server send requests to arbitrary addresses.
+func (e externalBots) newStrategy(endpoint string) (bot.Strategy, error) {
+	if len(e.endpoints) == 0 {
+		return nil, errForbiddenEndpoint
+	}
+	if endpoint == "" {
+		endpoint = e.endpoints[0]
+	}
+
+	for _, allowed := range e.endpoints {
+		if endpoint == allowed {
+			options := append([]bot.APIOption{bot.WithEndpoint(endpoint)}, e.options...)
+			return bot.NewAPI(options...), nil
+		}
+	}
+	return nil, errForbiddenEndpoint
+}
+
+// setupPlayers describes every seat of the game.
+// The user's seat is controlled by a human, the other seats by bots
+// with the given levels, unless the players slice says otherwise.
+// The other human seats are played by the user too, in turns at the same device.
+func setupPlayers(g *game.Game, userId int, botLevels []botRef, players []game.PlayerInfo) error {
+	if len(players) > len(g.Players) {
+		return errIncorrectPlayerLen
+	}
+
+	for id := range g.Players {
+		var info game.PlayerInfo
+		if id < len(players) {
+			info = players[id]
+		}
+
+		if info.Controller.Kind == "" {
+			if id == userId {
+				info.Controller = game.Controller{Kind: game.ControllerHuman}
+			} else {
+				info.Controller = game.Controller{Kind: game.ControllerBot}
+				if id < len(botLevels) {
+					info.Controller.Level = botLevels[id].Level
+					info.Controller.Bot = botLevels[id].Name
+				}
+			}
+		}
+
+		if id == userId && info.Controller.Kind != game.ControllerHuman {
+			return errIncorrectUserSeat
+		}
+
+		if err := g.SetPlayerInfo(id, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setupHandicaps gives the starting bonuses to the players by their seats.
+func setupHandicaps(g *game.Game, handicaps []game.Handicap) error {
+	if len(handicaps) > len(g.Players) {
+		return errIncorrectHandicaps
+	}
+
+	for id, handicap := range handicaps {
+		if err := g.SetHandicap(id, handicap); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // attack performs an attack from a source cell to a target cell.
@@ -61,7 +377,7 @@ func (u *User) attack(from, to game.Coords) error {
 
 	fromCell := g.Board.Cells[from.Row][from.Col]
 	toCell := g.Board.Cells[to.Row][to.Col]
-	return g.Attack(g.Players[u.GameBox.UserId], fromCell, toCell)
+	return g.Attack(u.me(), fromCell, toCell)
 }
 
 // endAttack ends the current attack phase for the user.
@@ -91,6 +407,51 @@ func (u *User) makeUpgrade(cellCoords game.Coords, levels int) error {
 	return g.Upgrade(u.me(), cell, levels)
 }
 
+// upgradeHint returns the upgrades that best strengthen the user's frontier with the user's points.
+func (u *User) upgradeHint() ([]bot.Upgrade, error) {
+	g := u.GameBox.Game
+
+	if g == nil {
+		return nil, errGameIsNotExist
+	}
+
+	upgrades := bot.PlanUpgrades(g, u.me())
+	if upgrades == nil {
+		upgrades = []bot.Upgrade{}
+	}
+	return upgrades, nil
+}
+
+// hint returns the next move the bot with the given name suggests to the user.
+// The game is not changed.
+func (u *User) hint(ctx context.Context, botName string) (bot.Hint, error) {
+	g := u.GameBox.Game
+
+	if g == nil {
+		return bot.Hint{}, errGameIsNotExist
+	}
+
+	strategy, err := bot.New(botName)
+	if err != nil {
+		return bot.Hint{}, err
+	}
+	defer closeBots(map[int]bot.Strategy{u.GameBox.UserId: strategy})
+
+	return bot.Suggest(ctx, g, u.me(), strategy)
+}
+
+// botTrace returns the decisions of the bots in the current game.
+func (u *User) botTrace() ([]bot.Decision, error) {
+	if u.GameBox.Game == nil {
+		return nil, errGameIsNotExist
+	}
+	if u.GameBox.trace == nil {
+		return nil, errTraceDisabled
+	}
+
+	return u.GameBox.trace.Decisions(), nil
+}
+
 // endTurn ends the current turn for the user.
 func (u *User) endTurn() error {
 	g := u.GameBox.Game
@@ -99,5 +460,87 @@ func (u *User) endTurn() error {
 		return errGameIsNotExist
 	}
 
-	return g.EndTurn(g.Players[u.GameBox.UserId])
+	return g.EndTurn(u.me())
+}
+
+// pass skips the current turn for the user.
+func (u *User) pass() error {
+	g := u.GameBox.Game
+
+	if g == nil {
+		return errGameIsNotExist
+	}
+
+	return g.Pass(u.me())
+}
+
+// resign removes the user from the game.
+func (u *User) resign() error {
+	g := u.GameBox.Game
+
+	if g == nil {
+		return errGameIsNotExist
+	}
+
+	return g.Resign(u.me())
+}
+
+// offerDraw offers the other players to finish the game in a draw.
+// The bots answer the offer at once, so a game against the bots alone is finished or goes on.
+func (u *User) offerDraw() error {
+	g := u.GameBox.Game
+
+	if g == nil {
+		return errGameIsNotExist
+	}
+
+	if err := g.OfferDraw(u.me()); err != nil {
+		return err
+	}
+	return u.answerDrawByBots()
+}
+
+// answerDrawByBots lets every bot with cells accept or decline the draw offer.
+// The first bot that declines rejects the offer.
+func (u *User) answerDrawByBots() error {
+	g := u.GameBox.Game
+
+	for _, player := range g.Players {
+		if _, ok := u.GameBox.bots[player.Id()]; !ok || player.CellsCount() == 0 {
+			continue
+		}
+		if g.IsFinished() {
+			return nil
+		}
+
+		if !bot.AcceptsDraw(g, player) {
+			return g.DeclineDraw(player)
+		}
+		if err := g.AcceptDraw(player); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// acceptDraw agrees to the draw offered by another player.
+func (u *User) acceptDraw() error {
+	g := u.GameBox.Game
+
+	if g == nil {
+		return errGameIsNotExist
+	}
+
+	return g.AcceptDraw(u.me())
+}
+
+// declineDraw rejects the draw offered by another player.
+func (u *User) declineDraw() error {
+	g := u.GameBox.Game
+
+	if g == nil {
+		return errGameIsNotExist
+	}
+
+	return g.DeclineDraw(u.me())
 }
@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"math/rand"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+// adaptiveLadder holds the names of the bots the adaptive bot switches between, from the weakest.
+var adaptiveLadder = []string{"random", "greedy", "chain", "mcts"}
+
+func init() {
+	Register(Info{
+		Name:        "adaptive",
+		Description: "Switches between weaker and stronger bots to keep the game close",
+		Level:       -1,
+	}, func() Strategy { return NewAdaptive(-1, 1) })
+}
+
+// Adaptive is the bot that adjusts its strength to the tracked player. Its skill is a position
+// on the ladder of bots from random to mcts: every turn it plays as the bot below the skill
+// or, with the probability of the fractional part, as the bot above it. After every round
+// the skill grows by the territory margin of the tracked player, so that a leading player
+// meets a stronger bot and a losing one a weaker bot.
+type Adaptive struct {
+	Target     int     // ID of the tracked player, -1 for the opponent with most cells
+	Skill      float64 // Position on the ladder, from 0 to the count of the bots minus one
+	MarginStep float64 // Change of the skill per round when the tracked player owns all cells
+
+	rungs []Strategy
+	level int // Rung played in the current turn
+	round int // Round the skill was last adjusted in
+	rand  *rand.Rand
+}
+
+// NewAdaptive creates the adaptive bot tracking the player with the given ID, starting at the skill.
+func NewAdaptive(target int, skill float64) *Adaptive {
+	a := &Adaptive{
+		MarginStep: 1,
+		rand:       newRand(),
+	}
+	for _, name := range adaptiveLadder {
+		strategy, err := New(name)
+		if err != nil {
+			panic("bot: adaptive ladder has unknown bot " + name)
+		}
+		a.rungs = append(a.rungs, strategy)
+	}
+	a.Start(target, skill)
+	return a
+}
+
+// Start makes the bot track the player with the given ID in a new game, starting at the skill.
+func (a *Adaptive) Start(target int, skill float64) {
+	a.Target = target
+	a.Skill = a.clamp(skill)
+	a.level = int(math.Floor(a.Skill))
+	a.round = -1
+}
+
+// MaxSkill returns the highest skill of the adaptive bots.
+func MaxSkill() float64 {
+	return float64(len(adaptiveLadder) - 1)
+}
+
+// Level returns the rung of the ladder played in the current turn: 0 for random, 1 for greedy,
+// 2 for chain and 3 for mcts.
+func (a *Adaptive) Level() int {
+	return a.level
+}
+
+// Playing returns the name of the bot of the rung played in the current turn.
+func (a *Adaptive) Playing() string {
+	return adaptiveLadder[a.level]
+}
+
+// PlanAttack returns the attack of the bot of the current rung.
+func (a *Adaptive) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	return a.strategy(g, player).PlanAttack(ctx, g, player)
+}
+
+// PlanUpgrade returns the upgrade of the bot of the current rung.
+func (a *Adaptive) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	return a.strategy(g, player).PlanUpgrade(ctx, g, player)
+}
+
+// strategy adjusts the skill and chooses the rung at the start of every turn
+// and returns the bot of the rung.
+func (a *Adaptive) strategy(g *game.Game, player game.Player) Strategy {
+	if round := g.TurnsCount(); round != a.round {
+		if a.round >= 0 {
+			a.Skill += a.MarginStep * a.margin(g, player)
+		}
+		a.Skill = a.clamp(a.Skill)
+		a.round = round
+
+		a.level = int(math.Floor(a.Skill))
+		if a.rand.Float64() < a.Skill-float64(a.level) {
+			a.level++
+		}
+	}
+	return a.rungs[a.level]
+}
+
+// margin returns the share of the cells of the board the tracked player owns more than the bot.
+func (a *Adaptive) margin(g *game.Game, player game.Player) float64 {
+	target := a.Target
+	if target < 0 || target >= len(g.Players) {
+		target = -1
+		for _, p := range g.Players {
+			if p.Id() != player.Id() && (target < 0 || p.CellsCount() > g.Players[target].CellsCount()) {
+				target = p.Id()
+			}
+		}
+	}
+
+	cells := 0
+	for _, row := range g.Board.Cells {
+		for _, cell := range row {
+			if cell != nil {
+				cells++
+			}
+		}
+	}
+	if target < 0 || cells == 0 {
+		return 0
+	}
+	return float64(g.Players[target].CellsCount()-player.CellsCount()) / float64(cells)
+}
+
+// clamp limits the skill to the ladder.
+func (a *Adaptive) clamp(skill float64) float64 {
+	return math.Max(0, math.Min(float64(len(a.rungs)-1), skill))
+}
+
+// setRand gives the source of the random choices to the bot and its rungs.
+func (a *Adaptive) setRand(r *rand.Rand) {
+	a.rand = r
+	for _, rung := range a.rungs {
+		seedStrategy(rung, childRand(r))
+	}
+}
@@ -0,0 +1,51 @@
+package bot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// playAdaptive plays the rounds of the game where the player 0 with the handicap never moves
+// and the adaptive bot of the player 1 starts at the skill. It returns the bot.
+func playAdaptive(t *testing.T, handicap game.Handicap, skill float64, rounds int) *bot.Adaptive {
+	t.Helper()
+
+	g, err := game.NewGame(7, 7, 2, 1)
+	require.NoError(t, err)
+	require.NoError(t, g.SetHandicap(0, handicap))
+
+	adaptive := bot.NewAdaptive(0, skill)
+	bot.Seed(adaptive, g.Seed(), 1)
+	strategies := []bot.Strategy{passiveStrategy{}, adaptive}
+	for g.TurnsCount() < rounds && !g.IsFinished() {
+		player := g.Players[g.Turn()]
+		require.NoError(t, bot.PlayTurn(context.Background(), g, player, strategies[player.Id()], bot.Budget{}))
+		assert.GreaterOrEqual(t, adaptive.Level(), 0)
+		assert.LessOrEqual(t, float64(adaptive.Level()), bot.MaxSkill())
+	}
+	return adaptive
+}
+
+func TestAdaptive(t *testing.T) {
+	t.Run("leading player meets a stronger bot", func(t *testing.T) {
+		adaptive := playAdaptive(t, game.Handicap{Cells: 10}, 0, 3)
+		assert.Greater(t, adaptive.Skill, 0.0)
+	})
+
+	t.Run("losing player meets a weaker bot", func(t *testing.T) {
+		adaptive := playAdaptive(t, game.Handicap{}, 1.5, 3)
+		assert.Less(t, adaptive.Skill, 1.5)
+	})
+
+	t.Run("skill is limited by the ladder", func(t *testing.T) {
+		adaptive := bot.NewAdaptive(0, 10)
+		assert.Equal(t, bot.MaxSkill(), adaptive.Skill)
+		assert.Equal(t, 3, adaptive.Level())
+		assert.Equal(t, "mcts", adaptive.Playing())
+	})
+}
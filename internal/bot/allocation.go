@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+func init() {
+	Register(Info{
+		Name:        "planner",
+		Description: "Makes the best scored attack and spends its points on the upgrades that most strengthen its frontier",
+		Level:       -1,
+	}, func() Strategy { return WithUpgradePlanner(greedyStrategy{}) })
+}
+
+// PlanUpgrades returns the upgrades the player can afford with its points
+// that give the most power to its frontier cells on the next turn.
+// A level of a cell adds a power to every neighbor of the same owner,
+// so the allocation is solved as a knapsack over the levels of every cell.
+// Among the best allocations the cheapest one is returned, nil if no upgrade strengthens the frontier.
+func PlanUpgrades(g *game.Game, player game.Player) []Upgrade {
+	points := player.Points()
+
+	type option struct {
+		cell  game.Cell
+		value int // Frontier power the cell gives per level
+	}
+	var options []option
+	for _, cell := range ownedCells(g, player) {
+		value := 0
+		for _, neighbor := range cell.GetNeighbors(g.Board) {
+			if neighbor.Owner() == player && isFrontier(g, neighbor) {
+				value++
+			}
+		}
+		if value > 0 && upgradeCost(cell) <= points {
+			options = append(options, option{cell: cell, value: value})
+		}
+	}
+
+	// best[i][b] is the most frontier power of the first i cells for exactly b points, -1 if impossible
+	// levels[i][b] is the count of levels of the i-th cell in that allocation
+	best := make([][]int, len(options)+1)
+	levels := make([][]int, len(options)+1)
+	for i := range best {
+		best[i] = make([]int, points+1)
+		levels[i] = make([]int, points+1)
+	}
+	for b := 1; b <= points; b++ {
+		best[0][b] = -1
+	}
+
+	for i, opt := range options {
+		copy(best[i+1], best[i])
+		for b := 0; b <= points; b++ {
+			if best[i][b] < 0 {
+				continue
+			}
+			for k := 1; b+upgradeCostLevels(opt.cell, k) <= points; k++ {
+				cost := b + upgradeCostLevels(opt.cell, k)
+				if value := best[i][b] + k*opt.value; value > best[i+1][cost] {
+					best[i+1][cost] = value
+					levels[i+1][cost] = k
+				}
+			}
+		}
+	}
+
+	spent := 0
+	for b, value := range best[len(options)] {
+		if value > best[len(options)][spent] {
+			spent = b
+		}
+	}
+
+	var upgrades []Upgrade
+	for i := len(options); i > 0; i-- {
+		if k := levels[i][spent]; k > 0 {
+			upgrades = append(upgrades, Upgrade{Cell: options[i-1].cell.Coords(), Levels: k})
+			spent -= upgradeCostLevels(options[i-1].cell, k)
+		}
+	}
+
+	// Keep the order of the cells on the board
+	for i, j := 0, len(upgrades)-1; i < j; i, j = i+1, j-1 {
+		upgrades[i], upgrades[j] = upgrades[j], upgrades[i]
+	}
+	return upgrades
+}
+
+// plannedStrategy upgrades cells by PlanUpgrades and leaves the rest of the moves to another strategy.
+type plannedStrategy struct {
+	Strategy
+}
+
+// WithUpgradePlanner returns the strategy that attacks like the given one,
+// but spends its points by PlanUpgrades. When no planned upgrade strengthens the frontier,
+// the upgrades of the given strategy are made.
+func WithUpgradePlanner(strategy Strategy) Strategy {
+	return plannedStrategy{Strategy: strategy}
+}
+
+// PlanUpgrade returns the first upgrade of the best allocation of the player's points.
+func (s plannedStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	if upgrades := PlanUpgrades(g, player); len(upgrades) > 0 {
+		return &upgrades[0], nil
+	}
+	return s.Strategy.PlanUpgrade(ctx, g, player)
+}
+
+// setRand gives the source of the random choices to the wrapped strategy.
+func (s plannedStrategy) setRand(r *rand.Rand) {
+	seedStrategy(s.Strategy, r)
+}
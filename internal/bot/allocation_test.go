@@ -0,0 +1,71 @@
+package bot_test
+
+import (
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// frontierPower returns the power the upgrades give to the frontier cells of the player.
+func frontierPower(t *testing.T, g *game.Game, player game.Player, upgrades []bot.Upgrade) (power, cost int) {
+	for _, upgrade := range upgrades {
+		cell, err := g.Board.GetCell(upgrade.Cell)
+		require.NoError(t, err)
+		require.Equal(t, player, cell.Owner())
+
+		for level := cell.Level(); level < cell.Level()+upgrade.Levels; level++ {
+			cost += level * (level + 1) / 2
+		}
+		for _, neighbor := range cell.GetNeighbors(g.Board) {
+			if neighbor.Owner() != player {
+				continue
+			}
+			for _, next := range neighbor.GetNeighbors(g.Board) {
+				if next.Owner() != player {
+					power += upgrade.Levels
+					break
+				}
+			}
+		}
+	}
+	return power, cost
+}
+
+func TestPlanUpgrades(t *testing.T) {
+	g, err := game.TestGameAttack()
+	require.NoError(t, err)
+	player := g.Players[0]
+
+	upgrades := bot.PlanUpgrades(g, player)
+	require.NotEmpty(t, upgrades)
+
+	power, cost := frontierPower(t, g, player, upgrades)
+	assert.LessOrEqual(t, cost, player.Points())
+
+	// Spending all points on any single cell is not better than the allocation
+	for _, row := range g.Board.Cells {
+		for _, cell := range row {
+			if cell == nil || cell.Owner() != player {
+				continue
+			}
+			for levels := 1; ; levels++ {
+				single := []bot.Upgrade{{Cell: cell.Coords(), Levels: levels}}
+				singlePower, singleCost := frontierPower(t, g, player, single)
+				if singleCost > player.Points() {
+					break
+				}
+				assert.LessOrEqual(t, singlePower, power)
+			}
+		}
+	}
+}
+
+func TestPlanUpgrades_noPoints(t *testing.T) {
+	g, err := game.NewGame(5, 5, 2, 0)
+	require.NoError(t, err)
+
+	assert.Empty(t, bot.PlanUpgrades(g, g.Players[0]))
+}
@@ -0,0 +1,480 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+var errSearchTimeout = errors.New("search time is over")
+
+func init() {
+	Register(Info{
+		Name:        "alphabeta",
+		Description: "Deterministic minimax search over whole turns, for games of two players",
+		Level:       -1,
+	}, func() Strategy { return NewAlphaBeta() })
+}
+
+// Weights of the position evaluation.
+const (
+	winScore       = 1e9
+	cellWeight     = 10
+	levelWeight    = 3
+	frontierWeight = 2
+	pointsWeight   = 1
+)
+
+// alphaBetaStrategy searches whole turns of both players with negamax and alpha-beta pruning.
+// A turn is one of a few candidates: sequences of attacks built from the best first attacks,
+// each followed by one of the upgrade plans. Games of more than two players are played greedily.
+type alphaBetaStrategy struct {
+	depth        int           // Max depth of search in turns
+	budget       time.Duration // Max time of search per turn, 0 means no limit
+	firstAttacks int           // Count of the best first attacks that start attack sequences
+
+	table    map[uint64]ttEntry
+	deadline time.Time
+	steps    []planStep // Rest of the planned turn
+}
+
+// AlphaBetaOption configures the alpha-beta search bot.
+type AlphaBetaOption func(*alphaBetaStrategy)
+
+// NewAlphaBeta creates an alpha-beta search bot.
+// Without a time budget the bot is deterministic.
+func NewAlphaBeta(options ...AlphaBetaOption) Strategy {
+	s := &alphaBetaStrategy{
+		depth:        3,
+		firstAttacks: 4,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// WithDepth sets the max depth of search in turns.
+func WithDepth(depth int) AlphaBetaOption {
+	return func(s *alphaBetaStrategy) {
+		s.depth = depth
+	}
+}
+
+// WithSearchBudget sets the max time of search per turn.
+// The bot plays the best turn found by the deepest finished iteration.
+func WithSearchBudget(budget time.Duration) AlphaBetaOption {
+	return func(s *alphaBetaStrategy) {
+		s.budget = budget
+	}
+}
+
+// ttFlag tells how the stored value bounds the real one.
+type ttFlag int
+
+const (
+	ttExact ttFlag = iota
+	ttLower
+	ttUpper
+)
+
+// ttEntry is an entry of the transposition table.
+type ttEntry struct {
+	depth int
+	value float64
+	flag  ttFlag
+	best  uint64 // Hash of the position after the best turn
+}
+
+// planStep is a move of the planned turn.
+// Both moves are nil for the step that ends the phase.
+type planStep struct {
+	hash    uint64 // Hash of the position the move is planned for
+	attack  *Attack
+	upgrade *Upgrade
+}
+
+// turnCandidate is a turn the search considers.
+type turnCandidate struct {
+	steps  []planStep
+	result *game.Game // Game after the turn
+	hash   uint64     // Hash of the game after the turn
+}
+
+// PlanAttack returns the next attack of the best turn.
+func (s *alphaBetaStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	if len(g.Players) != 2 {
+		return greedyStrategy{}.PlanAttack(ctx, g, player)
+	}
+
+	step := s.nextStep(ctx, g, player, true)
+	return step.attack, nil
+}
+
+// PlanUpgrade returns the next upgrade of the best turn.
+func (s *alphaBetaStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	if len(g.Players) != 2 {
+		return greedyStrategy{}.PlanUpgrade(ctx, g, player)
+	}
+
+	step := s.nextStep(ctx, g, player, false)
+	return step.upgrade, nil
+}
+
+// nextStep returns the next move of the planned turn.
+// If the game has gone off the plan, the rest of the turn is searched again.
+func (s *alphaBetaStrategy) nextStep(ctx context.Context, g *game.Game, player game.Player, attacking bool) planStep {
+	hash := positionHash(g, attacking)
+	if len(s.steps) == 0 || s.steps[0].hash != hash {
+		s.steps = s.search(ctx, g, player, attacking)
+	}
+	if len(s.steps) == 0 {
+		return planStep{}
+	}
+
+	step := s.steps[0]
+	s.steps = s.steps[1:]
+	return step
+}
+
+// search returns the steps of the best turn found by iterative deepening.
+// When the budget runs out or the context is done, the turn found
+// by the deepest finished iteration is returned.
+func (s *alphaBetaStrategy) search(ctx context.Context, g *game.Game, player game.Player, attacking bool) []planStep {
+	candidates := s.candidateTurns(g, player, attacking)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	s.table = make(map[uint64]ttEntry)
+	if s.budget > 0 {
+		s.deadline = time.Now().Add(s.budget)
+	} else {
+		s.deadline = time.Time{}
+	}
+
+	// Without finished iterations the turn with the best static evaluation is played
+	s.orderCandidates(candidates, positionHash(g, attacking))
+	best := candidates[0]
+	for depth := 1; depth <= s.depth; depth++ {
+		found, err := s.searchRoot(ctx, g, candidates, depth, attacking)
+		if err != nil {
+			break
+		}
+		best = found
+	}
+	return best.steps
+}
+
+// searchRoot searches the turns of the player to move with the given depth.
+func (s *alphaBetaStrategy) searchRoot(ctx context.Context, g *game.Game, candidates []turnCandidate, depth int, attacking bool) (turnCandidate, error) {
+	hash := positionHash(g, attacking)
+	s.orderCandidates(candidates, hash)
+
+	alpha, beta := math.Inf(-1), math.Inf(1)
+	best := candidates[0]
+	for _, candidate := range candidates {
+		value, err := s.negamax(ctx, candidate.result, depth-1, -beta, -alpha)
+		if err != nil {
+			return turnCandidate{}, err
+		}
+		value = -value
+
+		if value > alpha {
+			alpha = value
+			best = candidate
+		}
+	}
+
+	s.table[hash] = ttEntry{depth: depth, value: alpha, flag: ttExact, best: best.hash}
+	return best, nil
+}
+
+// negamax returns the value of the position for the player to move.
+func (s *alphaBetaStrategy) negamax(ctx context.Context, g *game.Game, depth int, alpha, beta float64) (float64, error) {
+	if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		return 0, errSearchTimeout
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	player := g.Players[g.Turn()]
+	if depth == 0 || g.IsFinished() {
+		return evaluatePosition(g, player), nil
+	}
+
+	hash := positionHash(g, true)
+	entry, ok := s.table[hash]
+	if ok && entry.depth >= depth {
+		switch {
+		case entry.flag == ttExact:
+			return entry.value, nil
+		case entry.flag == ttLower && entry.value >= beta:
+			return entry.value, nil
+		case entry.flag == ttUpper && entry.value <= alpha:
+			return entry.value, nil
+		}
+	}
+
+	candidates := s.candidateTurns(g, player, true)
+	if len(candidates) == 0 {
+		return evaluatePosition(g, player), nil
+	}
+	s.orderCandidates(candidates, hash)
+
+	alphaOrig := alpha
+	bestValue := math.Inf(-1)
+	var bestHash uint64
+	for _, candidate := range candidates {
+		value, err := s.negamax(ctx, candidate.result, depth-1, -beta, -alpha)
+		if err != nil {
+			return 0, err
+		}
+		value = -value
+
+		if value > bestValue {
+			bestValue = value
+			bestHash = candidate.hash
+		}
+		alpha = math.Max(alpha, value)
+		if alpha >= beta {
+			break
+		}
+	}
+
+	flag := ttExact
+	if bestValue <= alphaOrig {
+		flag = ttUpper
+	} else if bestValue >= beta {
+		flag = ttLower
+	}
+	s.table[hash] = ttEntry{depth: depth, value: bestValue, flag: flag, best: bestHash}
+
+	return bestValue, nil
+}
+
+// orderCandidates puts the best turn of the previous iteration first,
+// then the turns with the best static evaluation for the player who makes them.
+func (s *alphaBetaStrategy) orderCandidates(candidates []turnCandidate, hash uint64) {
+	best := s.table[hash].best
+	scores := make(map[uint64]float64, len(candidates))
+	for _, candidate := range candidates {
+		// The next player evaluates the position, so the mover's score is the opposite
+		next := candidate.result.Players[candidate.result.Turn()]
+		scores[candidate.hash] = -evaluatePosition(candidate.result, next)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].hash == best || candidates[j].hash == best {
+			return candidates[i].hash == best && candidates[j].hash != best
+		}
+		return scores[candidates[i].hash] > scores[candidates[j].hash]
+	})
+}
+
+// candidateTurns returns the distinct turns the search considers for the player.
+func (s *alphaBetaStrategy) candidateTurns(g *game.Game, player game.Player, attacking bool) []turnCandidate {
+	if g.IsFinished() || g.Turn() != player.Id() {
+		return nil
+	}
+
+	var afterAttacks []turnCandidate
+	if attacking {
+		afterAttacks = s.attackSequences(g, player)
+	} else {
+		afterAttacks = []turnCandidate{{result: g.Clone()}}
+	}
+
+	seen := make(map[uint64]bool)
+	var candidates []turnCandidate
+	for _, sequence := range afterAttacks {
+		for _, planUpgrades := range []func(*game.Game, game.Player) []planStep{planGreedyUpgrades, planFrontierUpgrades} {
+			result := sequence.result.Clone()
+			steps := append([]planStep{}, sequence.steps...)
+			steps = append(steps, planUpgrades(result, result.Players[player.Id()])...)
+
+			hash := positionHash(result, true)
+			if seen[hash] {
+				continue
+			}
+			seen[hash] = true
+
+			candidates = append(candidates, turnCandidate{steps: steps, result: result, hash: hash})
+		}
+	}
+	return candidates
+}
+
+// attackSequences returns the games after the attack phase: without attacks
+// and after greedy sequences that start with each of the best first attacks.
+func (s *alphaBetaStrategy) attackSequences(g *game.Game, player game.Player) []turnCandidate {
+	attacks := legalAttacks(g, player)
+	sort.SliceStable(attacks, func(i, j int) bool {
+		return attackScore(g, attacks[i]) > attackScore(g, attacks[j])
+	})
+	if len(attacks) > s.firstAttacks {
+		attacks = attacks[:s.firstAttacks]
+	}
+
+	sequences := make([]turnCandidate, 0, len(attacks)+1)
+
+	noAttacks := g.Clone()
+	sequences = append(sequences, turnCandidate{
+		steps:  []planStep{endAttack(noAttacks, noAttacks.Players[player.Id()])},
+		result: noAttacks,
+	})
+
+	for _, first := range attacks {
+		first := first
+		result := g.Clone()
+		me := result.Players[player.Id()]
+
+		var steps []planStep
+		attack := &first
+		for attack != nil && !result.IsFinished() {
+			hash := positionHash(result, true)
+			if applyAttack(result, me, attack) != nil {
+				break
+			}
+			steps = append(steps, planStep{hash: hash, attack: attack})
+
+			attack, _ = greedyStrategy{}.PlanAttack(context.Background(), result, me)
+		}
+
+		if !result.IsFinished() {
+			steps = append(steps, endAttack(result, me))
+		}
+		sequences = append(sequences, turnCandidate{steps: steps, result: result})
+	}
+	return sequences
+}
+
+// endAttack ends the attack phase of the simulated game and returns its step.
+func endAttack(g *game.Game, player game.Player) planStep {
+	step := planStep{hash: positionHash(g, true)}
+	g.EndAttack(player)
+	return step
+}
+
+// planUpgrades makes the upgrades chosen by the function until it returns nil,
+// then ends the turn and returns the steps.
+func planUpgrades(g *game.Game, player game.Player, next func() *Upgrade) []planStep {
+	var steps []planStep
+	if g.IsFinished() {
+		return steps
+	}
+
+	for upgrade := next(); upgrade != nil; upgrade = next() {
+		hash := positionHash(g, false)
+		if applyUpgrade(g, player, upgrade) != nil {
+			break
+		}
+		steps = append(steps, planStep{hash: hash, upgrade: upgrade})
+	}
+
+	steps = append(steps, planStep{hash: positionHash(g, false)})
+	g.EndTurn(player)
+	return steps
+}
+
+// planGreedyUpgrades upgrades the cells the way the greedy bot does.
+func planGreedyUpgrades(g *game.Game, player game.Player) []planStep {
+	return planUpgrades(g, player, func() *Upgrade {
+		upgrade, _ := greedyStrategy{}.PlanUpgrade(context.Background(), g, player)
+		return upgrade
+	})
+}
+
+// planFrontierUpgrades upgrades the cells that boost the most frontier neighbors,
+// cheaper cells first.
+func planFrontierUpgrades(g *game.Game, player game.Player) []planStep {
+	return planUpgrades(g, player, func() *Upgrade {
+		var best game.Cell
+		bestBoost := 0
+		for _, cell := range ownedCells(g, player) {
+			if upgradeCost(cell) > player.Points() {
+				continue
+			}
+
+			boost := 0
+			for _, neighbor := range cell.GetNeighbors(g.Board) {
+				if neighbor.Owner() == player && isFrontier(g, neighbor) {
+					boost++
+				}
+			}
+			if boost > bestBoost || (boost == bestBoost && best != nil && boost > 0 && cell.Level() < best.Level()) {
+				best = cell
+				bestBoost = boost
+			}
+		}
+
+		if best == nil {
+			return nil
+		}
+		return &Upgrade{Cell: best.Coords(), Levels: 1}
+	})
+}
+
+// attackScore returns the score of the attack by the greedy evaluation.
+func attackScore(g *game.Game, attack Attack) int {
+	from, _ := g.Board.GetCell(attack.From)
+	to, _ := g.Board.GetCell(attack.To)
+	return calculateScore(from, to)
+}
+
+// isFrontier reports whether the cell has a neighbor owned by someone else.
+func isFrontier(g *game.Game, cell game.Cell) bool {
+	for _, neighbor := range cell.GetNeighbors(g.Board) {
+		if neighbor.Owner() != cell.Owner() {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluatePosition returns the value of the position for the player:
+// its territory, levels, frontier power and points against the opponents' ones.
+func evaluatePosition(g *game.Game, player game.Player) float64 {
+	if g.IsDraw() {
+		return 0
+	}
+	if winner := g.Winner(); winner != nil {
+		if winner.Id() == player.Id() {
+			return winScore
+		}
+		return -winScore
+	}
+
+	scores := make([]float64, len(g.Players))
+	for _, row := range g.Board.Cells {
+		for _, cell := range row {
+			if cell == nil || cell.Owner() == nil {
+				continue
+			}
+
+			score := cellWeight + levelWeight*float64(cell.Level()-1)
+			if isFrontier(g, cell) {
+				score += frontierWeight * float64(cell.Power())
+			}
+			scores[cell.Owner().Id()] += score
+		}
+	}
+
+	value := 0.0
+	for i, p := range g.Players {
+		score := scores[i] + pointsWeight*float64(p.Points())
+		if p.Id() == player.Id() {
+			value += score
+		} else {
+			value -= score
+		}
+	}
+	return value
+}
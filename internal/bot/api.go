@@ -0,0 +1,427 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+var (
+	errBotServerStatus     = errors.New("bot server responded with an error status")
+	errIllegalMove         = errors.New("bot server suggested an illegal move")
+	errUnsupportedProtocol = errors.New("bot server does not support the protocol version")
+	errMissingCapability   = errors.New("bot server lacks a required capability")
+)
+
+// DefaultAPIEndpoint is the address of the external bot server used when none is configured.
+const DefaultAPIEndpoint = "http://127.0.0.1:8000"
+
+// maxResponseSize limits the size of a response of the bot server.
+const maxResponseSize = 1 << 20
+
+// APIName is the name of the built-in bot played by the external bot server.
+const APIName = "api"
+
+func init() {
+	Register(Info{
+		Name:        APIName,
+		Description: "Asks the external bot server for every move",
+		Level:       2,
+	}, func() Strategy { return NewAPI() })
+}
+
+type BotAction struct {
+	Attack  [][]int `json:"attack"`
+	Upgrade []int   `json:"upgrade"`
+}
+
+// apiStrategy asks the external bot server for the moves.
+// The server plans whole turns with the versioned protocol,
+// or single moves with the legacy one if it is enabled.
+// If the server fails or suggests an illegal move, the move is planned by the fallback bot.
+type apiStrategy struct {
+	endpoint   string        // Base URL of the bot server
+	timeout    time.Duration // Max time of a single request
+	retries    int           // Count of extra attempts after a failed request
+	maxActions int           // Max count of moves per turn, 0 means no limit
+	fallback   string        // Name of the bot used when the server fails, empty for none
+	legacy     bool          // Ask for single moves at the legacy endpoints
+	client     *http.Client
+	rand       *rand.Rand // Source of the random choices of the fallback bot
+
+	turn    turnKey // Turn the actions are counted for
+	actions int     // Count of moves planned in the turn
+
+	hello *HelloResponse // Handshake of the server, nil until it succeeds
+	plan  turnPlan       // Plan of the current turn
+}
+
+// turnPlan holds the moves of the turn planned by the server that are not made yet.
+type turnPlan struct {
+	turn     turnKey
+	err      error // Error of the plan request, the fallback bot plays the rest of the turn
+	attacks  []Attack
+	upgrades []Upgrade
+}
+
+// turnKey identifies a turn of the game.
+type turnKey struct {
+	round  int
+	player int
+}
+
+// APIOption configures the external bot client.
+type APIOption func(*apiStrategy)
+
+// NewAPI creates a bot that asks the external bot server for every move.
+func NewAPI(options ...APIOption) Strategy {
+	s := &apiStrategy{
+		endpoint:   DefaultAPIEndpoint,
+		timeout:    5 * time.Second,
+		retries:    1,
+		maxActions: 100,
+		fallback:   "greedy",
+		client:     &http.Client{},
+		rand:       newRand(),
+		turn:       turnKey{round: -1},
+		plan:       turnPlan{turn: turnKey{round: -1}},
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// WithEndpoint sets the base URL of the bot server.
+func WithEndpoint(url string) APIOption {
+	return func(s *apiStrategy) {
+		s.endpoint = url
+	}
+}
+
+// WithRequestTimeout sets the max time of a single request to the bot server.
+func WithRequestTimeout(timeout time.Duration) APIOption {
+	return func(s *apiStrategy) {
+		s.timeout = timeout
+	}
+}
+
+// WithRetries sets the count of extra attempts after a failed request.
+func WithRetries(retries int) APIOption {
+	return func(s *apiStrategy) {
+		s.retries = retries
+	}
+}
+
+// WithMaxActions sets the max count of moves per turn, 0 means no limit.
+func WithMaxActions(actions int) APIOption {
+	return func(s *apiStrategy) {
+		s.maxActions = actions
+	}
+}
+
+// WithFallback sets the name of the bot that plans the moves when the server fails.
+// An empty name disables the fallback, so failures end the phase.
+func WithFallback(name string) APIOption {
+	return func(s *apiStrategy) {
+		s.fallback = name
+	}
+}
+
+// WithLegacyProtocol makes the client ask for single moves at the legacy
+// /ai_attack and /ai_upgrade endpoints instead of whole turns.
+func WithLegacyProtocol(legacy bool) APIOption {
+	return func(s *apiStrategy) {
+		s.legacy = legacy
+	}
+}
+
+// WithHTTPClient sets the client the requests are sent with.
+func WithHTTPClient(client *http.Client) APIOption {
+	return func(s *apiStrategy) {
+		s.client = client
+	}
+}
+
+// PlanAttack asks the external bot server for the next attack.
+func (s *apiStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	if s.outOfActions(g) {
+		return nil, nil
+	}
+
+	var attack *Attack
+	var err error
+	if s.legacy {
+		attack, err = s.requestAttack(ctx, g, player)
+	} else {
+		attack, err = s.plannedAttack(ctx, g, player)
+	}
+	if err != nil {
+		fallback, fallbackErr := s.fallbackStrategy()
+		if fallbackErr != nil {
+			return nil, err
+		}
+		attack, err = fallback.PlanAttack(ctx, g, player)
+	}
+	if attack != nil {
+		s.actions++
+	}
+	return attack, err
+}
+
+// PlanUpgrade asks the external bot server for the next upgrade.
+func (s *apiStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	if s.outOfActions(g) {
+		return nil, nil
+	}
+
+	var upgrade *Upgrade
+	var err error
+	if s.legacy {
+		upgrade, err = s.requestUpgrade(ctx, g, player)
+	} else {
+		upgrade, err = s.plannedUpgrade(ctx, g, player)
+	}
+	if err != nil {
+		fallback, fallbackErr := s.fallbackStrategy()
+		if fallbackErr != nil {
+			return nil, err
+		}
+		upgrade, err = fallback.PlanUpgrade(ctx, g, player)
+	}
+	if upgrade != nil {
+		s.actions++
+	}
+	return upgrade, err
+}
+
+// outOfActions reports whether the bot has made the max count of moves in the current turn.
+func (s *apiStrategy) outOfActions(g *game.Game) bool {
+	turn := turnKey{round: g.TurnsCount(), player: g.Turn()}
+	if turn != s.turn {
+		s.turn = turn
+		s.actions = 0
+	}
+
+	return s.maxActions > 0 && s.actions >= s.maxActions
+}
+
+// fallbackStrategy creates the bot that plans the moves when the server fails.
+func (s *apiStrategy) fallbackStrategy() (Strategy, error) {
+	if s.fallback == "" {
+		return nil, errUnknownBot
+	}
+
+	fallback, err := New(s.fallback)
+	if err != nil {
+		return nil, err
+	}
+	seedStrategy(fallback, childRand(s.rand))
+	return fallback, nil
+}
+
+// setRand replaces the source of the random choices of the fallback bot.
+func (s *apiStrategy) setRand(r *rand.Rand) {
+	s.rand = r
+}
+
+// plannedAttack returns the next attack of the turn plan and checks that it is legal.
+// The plan is requested from the server at the first attack of the turn.
+func (s *apiStrategy) plannedAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	if err := s.requestPlan(ctx, g, player, PhaseAttack); err != nil {
+		return nil, err
+	}
+	if len(s.plan.attacks) == 0 {
+		return nil, nil
+	}
+
+	attack := s.plan.attacks[0]
+	s.plan.attacks = s.plan.attacks[1:]
+	if err := checkAttack(g, player, attack); err != nil {
+		s.abandonPlan(err)
+		return nil, err
+	}
+	return &attack, nil
+}
+
+// plannedUpgrade returns the next upgrade of the turn plan and checks that it is legal.
+// The plan is requested from the server if it has not been requested in the attack phase.
+func (s *apiStrategy) plannedUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	if err := s.requestPlan(ctx, g, player, PhaseUpgrade); err != nil {
+		return nil, err
+	}
+	if len(s.plan.upgrades) == 0 {
+		return nil, nil
+	}
+
+	upgrade := s.plan.upgrades[0]
+	s.plan.upgrades = s.plan.upgrades[1:]
+	if err := checkUpgrade(g, player, upgrade); err != nil {
+		s.abandonPlan(err)
+		return nil, err
+	}
+	return &upgrade, nil
+}
+
+// abandonPlan drops the rest of the turn plan after the error, so that the fallback bot plays the rest of the turn.
+func (s *apiStrategy) abandonPlan(err error) {
+	s.plan.err = err
+	s.plan.attacks = nil
+	s.plan.upgrades = nil
+}
+
+// requestPlan asks the bot server for the plan of the current turn, unless it is already planned.
+func (s *apiStrategy) requestPlan(ctx context.Context, g *game.Game, player game.Player, phase Phase) error {
+	turn := turnKey{round: g.TurnsCount(), player: g.Turn()}
+	if s.plan.turn == turn {
+		return s.plan.err
+	}
+
+	s.plan = turnPlan{turn: turn}
+	s.plan.err = s.handshake(ctx)
+	if s.plan.err != nil {
+		return s.plan.err
+	}
+
+	var plan Plan
+	s.plan.err = s.request(ctx, "/v1/plan", NewState(g, player, phase), &plan)
+	if s.plan.err != nil {
+		return s.plan.err
+	}
+
+	if phase == PhaseAttack {
+		s.plan.attacks = plan.Attacks
+	}
+	s.plan.upgrades = plan.Upgrades
+	return nil
+}
+
+// handshake agrees on the protocol version with the bot server, unless it is already done.
+func (s *apiStrategy) handshake(ctx context.Context) error {
+	if s.hello != nil {
+		return nil
+	}
+
+	var hello HelloResponse
+	if err := s.request(ctx, "/v1/hello", Hello{Versions: []int{ProtocolVersion}}, &hello); err != nil {
+		return err
+	}
+	if hello.Version != ProtocolVersion {
+		return fmt.Errorf("%w: %d", errUnsupportedProtocol, hello.Version)
+	}
+	if !slices.Contains(hello.Capabilities, CapabilityTurnPlan) {
+		return fmt.Errorf("%w: %s", errMissingCapability, CapabilityTurnPlan)
+	}
+
+	s.hello = &hello
+	return nil
+}
+
+// requestAttack asks the bot server for an attack and checks that it is legal.
+func (s *apiStrategy) requestAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	var action BotAction
+	if err := s.request(ctx, "/ai_attack", g.ToMap(), &action); err != nil {
+		return nil, err
+	}
+
+	// If the bot returns None (skips the turn)
+	if action.Attack == nil {
+		return nil, nil
+	}
+
+	if len(action.Attack) != 2 || len(action.Attack[0]) != 2 || len(action.Attack[1]) != 2 {
+		return nil, errMalformedResponse
+	}
+
+	attack := Attack{
+		From: game.Coords{Row: action.Attack[0][0], Col: action.Attack[0][1]},
+		To:   game.Coords{Row: action.Attack[1][0], Col: action.Attack[1][1]},
+	}
+	if err := checkAttack(g, player, attack); err != nil {
+		return nil, err
+	}
+	return &attack, nil
+}
+
+// requestUpgrade asks the bot server for an upgrade and checks that it is legal.
+func (s *apiStrategy) requestUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	var action BotAction
+	if err := s.request(ctx, "/ai_upgrade", g.ToMap(), &action); err != nil {
+		return nil, err
+	}
+
+	// If the bot returns None (skips the turn)
+	if action.Upgrade == nil {
+		return nil, nil
+	}
+
+	if len(action.Upgrade) != 2 {
+		return nil, errMalformedResponse
+	}
+
+	upgrade := Upgrade{
+		Cell:   game.Coords{Row: action.Upgrade[0], Col: action.Upgrade[1]},
+		Levels: 1,
+	}
+	if err := checkUpgrade(g, player, upgrade); err != nil {
+		return nil, err
+	}
+	return &upgrade, nil
+}
+
+// request sends the payload to the bot server and decodes the response into the result.
+// A failed request is repeated while there are retries left and the context is not done.
+func (s *apiStrategy) request(ctx context.Context, path string, payload any, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = s.post(ctx, s.endpoint+path, body, result)
+		if err == nil || attempt >= s.retries || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// post makes a single request to the bot server within the request timeout.
+func (s *apiStrategy) post(ctx context.Context, url string, body []byte, result any) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s", errBotServerStatus, resp.Status)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(result); err != nil {
+		return fmt.Errorf("%w: %v", errMalformedResponse, err)
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package bot_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBotServer starts a stand-in of the external bot server
+// that answers every request with the given status and the body of its path.
+func newBotServer(t *testing.T, status int, bodies map[string]string) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(status)
+		w.Write([]byte(bodies[r.URL.Path]))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// legacyBodies returns the responses of the legacy endpoints.
+func legacyBodies(attack, upgrade string) map[string]string {
+	return map[string]string{"/ai_attack": attack, "/ai_upgrade": upgrade}
+}
+
+// planBodies returns the responses of the versioned protocol endpoints.
+func planBodies(plan string) map[string]string {
+	return map[string]string{
+		"/v1/hello": `{"version": 1, "name": "test", "capabilities": ["turn_plan"]}`,
+		"/v1/plan":  plan,
+	}
+}
+
+func TestAPI_legacy(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+	player := g.Players[0]
+
+	server, _ := newBotServer(t, http.StatusOK, legacyBodies(`{"attack": [[0, 0], [0, 1]]}`, `{"upgrade": [0, 0]}`))
+	strategy := bot.NewAPI(bot.WithEndpoint(server.URL), bot.WithLegacyProtocol(true), bot.WithFallback(""))
+
+	attack, err := strategy.PlanAttack(context.Background(), g, player)
+	require.NoError(t, err)
+	assert.Equal(t, &bot.Attack{From: game.Coords{Row: 0, Col: 0}, To: game.Coords{Row: 0, Col: 1}}, attack)
+
+	require.NoError(t, g.SetHandicap(0, game.Handicap{Points: 10}))
+	require.NoError(t, g.EndAttack(player))
+	upgrade, err := strategy.PlanUpgrade(context.Background(), g, player)
+	require.NoError(t, err)
+	assert.Equal(t, &bot.Upgrade{Cell: game.Coords{Row: 0, Col: 0}, Levels: 1}, upgrade)
+}
+
+func TestAPI_badResponses(t *testing.T) {
+	testCases := []struct {
+		name   string
+		status int
+		attack string
+	}{
+		{name: "server error", status: http.StatusInternalServerError, attack: `{"attack": null}`},
+		{name: "not json", status: http.StatusOK, attack: `attack!`},
+		{name: "short coords", status: http.StatusOK, attack: `{"attack": [[0], [0, 1]]}`},
+		{name: "out of board", status: http.StatusOK, attack: `{"attack": [[0, 0], [100, -1]]}`},
+		{name: "foreign cell", status: http.StatusOK, attack: `{"attack": [[4, 4], [4, 3]]}`},
+		{name: "not neighbors", status: http.StatusOK, attack: `{"attack": [[0, 0], [3, 3]]}`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := game.NewCompleteBoardGame(5, 5, 2)
+			require.NoError(t, err)
+			player := g.Players[0]
+
+			server, _ := newBotServer(t, tc.status, legacyBodies(tc.attack, `{"upgrade": null}`))
+
+			// Without a fallback the phase ends with an error
+			strategy := bot.NewAPI(bot.WithEndpoint(server.URL), bot.WithLegacyProtocol(true), bot.WithFallback(""), bot.WithRetries(0))
+			attack, err := strategy.PlanAttack(context.Background(), g, player)
+			assert.Error(t, err)
+			assert.Nil(t, attack)
+
+			// The fallback bot plans the move instead
+			strategy = bot.NewAPI(bot.WithEndpoint(server.URL), bot.WithLegacyProtocol(true), bot.WithFallback("greedy"), bot.WithRetries(0))
+			attack, err = strategy.PlanAttack(context.Background(), g, player)
+			assert.NoError(t, err)
+			assert.NotNil(t, attack)
+
+			require.NoError(t, bot.PlayTurn(context.Background(), g, player, strategy, bot.Budget{}))
+			require.NoError(t, g.CheckInvariants())
+		})
+	}
+}
+
+func TestAPI_retries(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	server, requests := newBotServer(t, http.StatusBadGateway, nil)
+	strategy := bot.NewAPI(bot.WithEndpoint(server.URL), bot.WithLegacyProtocol(true), bot.WithFallback(""), bot.WithRetries(2))
+
+	_, err = strategy.PlanAttack(context.Background(), g, g.Players[0])
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+}
+
+func TestAPI_timeout(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	strategy := bot.NewAPI(
+		bot.WithEndpoint(server.URL),
+		bot.WithLegacyProtocol(true),
+		bot.WithFallback(""),
+		bot.WithRetries(1),
+		bot.WithRequestTimeout(20*time.Millisecond),
+	)
+
+	start := time.Now()
+	_, err = strategy.PlanAttack(context.Background(), g, g.Players[0])
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestAPI_maxActions(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+	player := g.Players[0]
+	require.NoError(t, g.SetHandicap(0, game.Handicap{Points: 1000}))
+
+	server, _ := newBotServer(t, http.StatusOK, legacyBodies(`{"attack": null}`, `{"upgrade": [0, 0]}`))
+	strategy := bot.NewAPI(bot.WithEndpoint(server.URL), bot.WithLegacyProtocol(true), bot.WithFallback(""), bot.WithMaxActions(3))
+
+	require.NoError(t, bot.PlayTurn(context.Background(), g, player, strategy, bot.Budget{}))
+	assert.Equal(t, 1+3, g.Board.Cells[0][0].Level())
+	assert.Equal(t, 1, g.Turn())
+
+	// The count starts over in the next turn
+	require.NoError(t, g.Pass(g.Players[1]))
+	require.NoError(t, bot.PlayTurn(context.Background(), g, player, strategy, bot.Budget{}))
+	assert.Equal(t, 1+6, g.Board.Cells[0][0].Level())
+}
+
+func TestAPI_plan(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+	player := g.Players[0]
+
+	var states []bot.State
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/hello":
+			w.Write([]byte(`{"version": 1, "name": "test", "capabilities": ["turn_plan"]}`))
+		case "/v1/plan":
+			var state bot.State
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&state))
+			states = append(states, state)
+			w.Write([]byte(`{
+				"attacks": [{"from": {"row": 0, "col": 0}, "to": {"row": 0, "col": 1}}],
+				"upgrades": [{"cell": {"row": 0, "col": 1}, "levels": 1}]
+			}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	strategy := bot.NewAPI(bot.WithEndpoint(server.URL), bot.WithFallback(""))
+	require.NoError(t, bot.PlayTurn(context.Background(), g, player, strategy, bot.Budget{}))
+	require.NoError(t, g.CheckInvariants())
+
+	// The whole turn is planned with one request
+	require.Len(t, states, 1)
+	assert.Equal(t, bot.ProtocolVersion, states[0].Version)
+	assert.Equal(t, bot.PhaseAttack, states[0].Phase)
+	assert.Equal(t, 0, states[0].Player)
+	require.NotNil(t, states[0].Board.Cells[0][0].Owner)
+	assert.Equal(t, 0, *states[0].Board.Cells[0][0].Owner)
+
+	assert.Equal(t, player, g.Board.Cells[0][1].Owner())
+	assert.Equal(t, 2, g.Board.Cells[0][1].Level())
+	assert.Equal(t, 1, g.Turn())
+}
+
+func TestAPI_badPlans(t *testing.T) {
+	testCases := []struct {
+		name   string
+		bodies map[string]string
+	}{
+		{
+			name: "unsupported version",
+			bodies: map[string]string{
+				"/v1/hello": `{"version": 2}`,
+				"/v1/plan":  `{"attacks": [], "upgrades": []}`,
+			},
+		},
+		{
+			name: "missing capability",
+			bodies: map[string]string{
+				"/v1/hello": `{"version": 1, "name": "test", "capabilities": []}`,
+				"/v1/plan":  `{"attacks": [], "upgrades": []}`,
+			},
+		},
+		{
+			name:   "illegal attack",
+			bodies: planBodies(`{"attacks": [{"from": {"row": 0, "col": 0}, "to": {"row": 3, "col": 3}}]}`),
+		},
+		{
+			name:   "attack out of board",
+			bodies: planBodies(`{"attacks": [{"from": {"row": -1, "col": 0}, "to": {"row": 0, "col": 0}}]}`),
+		},
+		{
+			name:   "not json",
+			bodies: planBodies(`plan!`),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := game.NewCompleteBoardGame(5, 5, 2)
+			require.NoError(t, err)
+			player := g.Players[0]
+
+			server, _ := newBotServer(t, http.StatusOK, tc.bodies)
+
+			// Without a fallback the phase ends with an error
+			strategy := bot.NewAPI(bot.WithEndpoint(server.URL), bot.WithFallback(""))
+			attack, err := strategy.PlanAttack(context.Background(), g, player)
+			assert.Error(t, err)
+			assert.Nil(t, attack)
+
+			// The fallback bot plans the move instead, and the rest of the turn
+			strategy = bot.NewAPI(bot.WithEndpoint(server.URL), bot.WithFallback("greedy"))
+			attack, err = strategy.PlanAttack(context.Background(), g, player)
+			assert.NoError(t, err)
+			require.NotNil(t, attack)
+
+			require.NoError(t, g.Attack(player, g.Board.Cells[attack.From.Row][attack.From.Col], g.Board.Cells[attack.To.Row][attack.To.Col]))
+			require.NoError(t, g.EndAttack(player))
+			upgrade, err := strategy.PlanUpgrade(context.Background(), g, player)
+			assert.NoError(t, err)
+			assert.NotNil(t, upgrade)
+		})
+	}
+}
+
+func TestAPI_illegalPlannedUpgrade(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+	player := g.Players[0]
+
+	// Upgrading by ten levels is too expensive
+	server, _ := newBotServer(t, http.StatusOK, planBodies(`{"upgrades": [{"cell": {"row": 0, "col": 0}, "levels": 10}]}`))
+	strategy := bot.NewAPI(bot.WithEndpoint(server.URL), bot.WithFallback(""))
+
+	require.NoError(t, g.EndAttack(player))
+	upgrade, err := strategy.PlanUpgrade(context.Background(), g, player)
+	assert.Error(t, err)
+	assert.Nil(t, upgrade)
+}
+
+func TestNewState(t *testing.T) {
+	// Odd rows of the board are one cell shorter
+	g, err := game.NewCompleteBoardGame(4, 5, 2)
+	require.NoError(t, err)
+
+	state := bot.NewState(g, g.Players[0], bot.PhaseAttack)
+	assert.Equal(t, 4, state.Board.Rows)
+	assert.Equal(t, 5, state.Board.Cols)
+	require.Len(t, state.Board.Cells, 4)
+	assert.Len(t, state.Board.Cells[0], 5)
+	assert.Len(t, state.Board.Cells[3], 4)
+}
+
+func TestPlan_Apply(t *testing.T) {
+	g, err := game.TestGameAttack()
+	require.NoError(t, err)
+	player := g.Players[0]
+
+	plan := bot.Plan{Attacks: bot.NewChainPlanner().Plan(context.Background(), g, player)}
+	require.NotEmpty(t, plan.Attacks)
+	clone := g.Clone()
+	require.NoError(t, plan.Apply(clone, clone.Players[0], bot.PhaseAttack))
+	assert.False(t, clone.Players[0].Attacking())
+
+	// The first illegal move fails the plan
+	plan.Attacks = append([]bot.Attack{{From: plan.Attacks[0].To, To: plan.Attacks[0].From}}, plan.Attacks...)
+	assert.ErrorContains(t, plan.Apply(g, player, bot.PhaseAttack), "illegal move")
+}
@@ -0,0 +1,244 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"sync"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+var errIncorrectBook = errors.New("incorrect opening book")
+
+// BookMove is a move of the opening book with the results of the games it was played in.
+// Both moves are nil for the end of the phase.
+type BookMove struct {
+	Attack  *Attack  `json:"attack,omitempty"`
+	Upgrade *Upgrade `json:"upgrade,omitempty"`
+	Plays   int      `json:"plays"` // Count of games the move was played in
+	Score   float64  `json:"score"` // Sum of the results of the player: 1 for a win, 0.5 for a draw
+}
+
+// rate returns the average result of the move.
+func (m BookMove) rate() float64 {
+	return m.Score / float64(m.Plays)
+}
+
+// sameMove reports whether the moves are equal, ignoring the statistics.
+func (m BookMove) sameMove(attack *Attack, upgrade *Upgrade) bool {
+	return equalPtr(m.Attack, attack) && equalPtr(m.Upgrade, upgrade)
+}
+
+// equalPtr reports whether both pointers are nil or point to equal values.
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Book holds the statistics of the moves played in the openings of self-play games,
+// keyed by the hash of the position they were played in. It is safe for concurrent use.
+type Book struct {
+	mu        sync.RWMutex
+	positions map[uint64][]BookMove
+}
+
+// NewBook creates an empty opening book.
+func NewBook() *Book {
+	return &Book{positions: make(map[uint64][]BookMove)}
+}
+
+// LoadBook reads the opening book from JSON, where the positions are keyed by their hex hashes.
+func LoadBook(r io.Reader) (*Book, error) {
+	var positions map[string][]BookMove
+	if err := json.NewDecoder(r).Decode(&positions); err != nil {
+		return nil, err
+	}
+
+	book := NewBook()
+	for key, moves := range positions {
+		hash, err := strconv.ParseUint(key, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: position %q", errIncorrectBook, key)
+		}
+		for _, move := range moves {
+			if move.Plays < 1 || move.Score < 0 || move.Score > float64(move.Plays) {
+				return nil, fmt.Errorf("%w: move statistics of position %q", errIncorrectBook, key)
+			}
+		}
+		book.positions[hash] = moves
+	}
+	return book, nil
+}
+
+// Save writes the opening book as JSON.
+func (b *Book) Save(w io.Writer) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	positions := make(map[string][]BookMove, len(b.positions))
+	for hash, moves := range b.positions {
+		positions[strconv.FormatUint(hash, 16)] = moves
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(positions)
+}
+
+// Len returns the count of positions in the book.
+func (b *Book) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.positions)
+}
+
+// add records the result of the move played in the position.
+func (b *Book) add(hash uint64, attack *Attack, upgrade *Upgrade, score float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	moves := b.positions[hash]
+	for i := range moves {
+		if moves[i].sameMove(attack, upgrade) {
+			moves[i].Plays++
+			moves[i].Score += score
+			return
+		}
+	}
+	b.positions[hash] = append(moves, BookMove{Attack: attack, Upgrade: upgrade, Plays: 1, Score: score})
+}
+
+// best returns the move with the best average result among the moves played at least minPlays times.
+// Ties are broken by the count of plays.
+func (b *Book) best(hash uint64, minPlays int) (BookMove, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var best BookMove
+	found := false
+	for _, move := range b.positions[hash] {
+		if move.Plays < minPlays {
+			continue
+		}
+		if !found || move.rate() > best.rate() || (move.rate() == best.rate() && move.Plays > best.Plays) {
+			best = move
+			found = true
+		}
+	}
+	return best, found
+}
+
+// bookStrategy plays the best moves of the opening book and leaves the rest to another strategy.
+type bookStrategy struct {
+	Strategy
+	book     *Book
+	minPlays int
+}
+
+// WithBook returns the strategy that plays the best legal move of the book in the known positions
+// and asks the given strategy otherwise. Only the moves played in at least minPlays games are trusted.
+func WithBook(strategy Strategy, book *Book, minPlays int) Strategy {
+	return bookStrategy{Strategy: strategy, book: book, minPlays: minPlays}
+}
+
+// PlanAttack returns the book attack, if the position is known.
+func (s bookStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	if move, ok := s.book.best(positionHash(g, true), s.minPlays); ok && move.Upgrade == nil {
+		if move.Attack == nil || checkAttack(g, player, *move.Attack) == nil {
+			considerBookMove(ctx, move)
+			return move.Attack, nil
+		}
+	}
+	return s.Strategy.PlanAttack(ctx, g, player)
+}
+
+// PlanUpgrade returns the book upgrade, if the position is known.
+func (s bookStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	if move, ok := s.book.best(positionHash(g, false), s.minPlays); ok && move.Attack == nil {
+		if move.Upgrade == nil || checkUpgrade(g, player, *move.Upgrade) == nil {
+			considerBookMove(ctx, move)
+			return move.Upgrade, nil
+		}
+	}
+	return s.Strategy.PlanUpgrade(ctx, g, player)
+}
+
+// considerBookMove records the book move in the decision traced by the context, if any.
+func considerBookMove(ctx context.Context, move BookMove) {
+	consider(ctx, Candidate{Attack: move.Attack, Upgrade: move.Upgrade, Score: move.rate()})
+}
+
+// setRand gives the source of the random choices to the wrapped strategy.
+func (s bookStrategy) setRand(r *rand.Rand) {
+	seedStrategy(s.Strategy, r)
+}
+
+// Close releases the resources of the wrapped strategy, if it has them.
+func (s bookStrategy) Close() error {
+	if closer, ok := s.Strategy.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// bookEntry is a move played in a position of the opening.
+type bookEntry struct {
+	hash    uint64
+	attack  *Attack
+	upgrade *Upgrade
+}
+
+// BookRecorder wraps a strategy and remembers its moves in the first rounds of a game,
+// so that they are added to the opening book with the result of the game.
+type BookRecorder struct {
+	Strategy
+	rounds  int
+	entries []bookEntry
+}
+
+// NewBookRecorder creates the recorder of the moves of the strategy in the first rounds of a game.
+func NewBookRecorder(strategy Strategy, rounds int) *BookRecorder {
+	return &BookRecorder{Strategy: strategy, rounds: rounds}
+}
+
+// PlanAttack returns the attack of the wrapped strategy and remembers it in the opening.
+func (r *BookRecorder) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	hash := positionHash(g, true)
+	attack, err := r.Strategy.PlanAttack(ctx, g, player)
+	if err == nil && g.TurnsCount() < r.rounds {
+		r.entries = append(r.entries, bookEntry{hash: hash, attack: attack})
+	}
+	return attack, err
+}
+
+// PlanUpgrade returns the upgrade of the wrapped strategy and remembers it in the opening.
+func (r *BookRecorder) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	hash := positionHash(g, false)
+	upgrade, err := r.Strategy.PlanUpgrade(ctx, g, player)
+	if err == nil && g.TurnsCount() < r.rounds {
+		r.entries = append(r.entries, bookEntry{hash: hash, upgrade: upgrade})
+	}
+	return upgrade, err
+}
+
+// Commit adds the remembered moves to the book with the result of the player:
+// 1 for a win, 0.5 for a draw and 0 for a loss. The recorder is emptied for the next game.
+func (r *BookRecorder) Commit(book *Book, score float64) {
+	for _, entry := range r.entries {
+		book.add(entry.hash, entry.attack, entry.upgrade, score)
+	}
+	r.entries = nil
+}
+
+// setRand gives the source of the random choices to the wrapped strategy.
+func (r *BookRecorder) setRand(rnd *rand.Rand) {
+	seedStrategy(r.Strategy, rnd)
+}
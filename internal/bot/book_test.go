@@ -0,0 +1,123 @@
+package bot_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedStrategy makes the given first attack, then ends the phases.
+type fixedStrategy struct {
+	attack *bot.Attack
+}
+
+func (s *fixedStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*bot.Attack, error) {
+	attack := s.attack
+	s.attack = nil
+	return attack, nil
+}
+
+func (s *fixedStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*bot.Upgrade, error) {
+	return nil, nil
+}
+
+// learnOpening records the opening of the strategy in the book with the score.
+func learnOpening(t *testing.T, book *bot.Book, strategy bot.Strategy, score float64) {
+	g, err := game.TestGameAttack()
+	require.NoError(t, err)
+
+	recorder := bot.NewBookRecorder(strategy, 1)
+	require.NoError(t, bot.PlayTurn(context.Background(), g, g.Players[0], recorder, bot.Budget{}))
+	recorder.Commit(book, score)
+}
+
+func TestBook(t *testing.T) {
+	winning := bot.Attack{From: game.Coords{Row: 1, Col: 0}, To: game.Coords{Row: 0, Col: 1}}
+	losing := bot.Attack{From: game.Coords{Row: 0, Col: 2}, To: game.Coords{Row: 0, Col: 1}}
+
+	book := bot.NewBook()
+	for i := 0; i < 3; i++ {
+		learnOpening(t, book, &fixedStrategy{attack: &winning}, 1)
+		learnOpening(t, book, &fixedStrategy{attack: &losing}, 0)
+	}
+	assert.NotZero(t, book.Len())
+
+	// The book survives saving
+	var buf bytes.Buffer
+	require.NoError(t, book.Save(&buf))
+	book, err := bot.LoadBook(&buf)
+	require.NoError(t, err)
+
+	g, err := game.TestGameAttack()
+	require.NoError(t, err)
+	passive := passiveStrategy{}
+
+	attack, err := bot.WithBook(passive, book, 3).PlanAttack(context.Background(), g, g.Players[0])
+	require.NoError(t, err)
+	require.NotNil(t, attack)
+	assert.Equal(t, winning, *attack)
+
+	// Moves played in fewer games are not trusted
+	attack, err = bot.WithBook(passive, book, 4).PlanAttack(context.Background(), g, g.Players[0])
+	require.NoError(t, err)
+	assert.Nil(t, attack)
+
+	// The same cells with another income are another position
+	g, err = game.TestGameAttack()
+	require.NoError(t, err)
+	require.NoError(t, g.SetHandicap(0, game.Handicap{Income: 2}))
+	attack, err = bot.WithBook(passive, book, 3).PlanAttack(context.Background(), g, g.Players[0])
+	require.NoError(t, err)
+	assert.Nil(t, attack)
+}
+
+func TestLoadBook(t *testing.T) {
+	testCases := []struct {
+		name    string
+		json    string
+		isValid bool
+	}{
+		{
+			name:    "valid",
+			json:    `{"1f": [{"attack": {"from": {"row": 0, "col": 0}, "to": {"row": 0, "col": 1}}, "plays": 2, "score": 1.5}]}`,
+			isValid: true,
+		},
+		{
+			name:    "empty",
+			json:    `{}`,
+			isValid: true,
+		},
+		{
+			name:    "incorrect hash",
+			json:    `{"position": []}`,
+			isValid: false,
+		},
+		{
+			name:    "score over plays",
+			json:    `{"1f": [{"plays": 1, "score": 2}]}`,
+			isValid: false,
+		},
+		{
+			name:    "malformed",
+			json:    `{"1f": `,
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := bot.LoadBook(strings.NewReader(tc.json))
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -1,47 +1,88 @@
 package bot
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
-	"io"
-	"math"
-	"math/rand"
-	"net/http"
-	"sort"
+	"time"
 
 	"github.com/Vacym/neighbors-force/internal/game"
 )
 
 var (
 	errIncorrectDifficulty = errors.New("incorrect difficulty level")
-	errNoPlayerCells       = func(playerId int) error {
-		return fmt.Errorf("no cells found for player %v", playerId)
-	}
+	errUnknownBot          = errors.New("unknown bot name")
+	errMalformedResponse   = errors.New("malformed response of the bot server")
+	errNotPlayerTurn       = errors.New("it is not the turn of the player")
 )
 
-type BotAction struct {
-	Attack  [][]int `json:"attack"`
-	Upgrade []int   `json:"upgrade"`
+// Attack is an attack planned by a bot.
+type Attack struct {
+	From game.Coords `json:"from"`
+	To   game.Coords `json:"to"`
+}
+
+// Upgrade is an upgrade planned by a bot.
+type Upgrade struct {
+	Cell   game.Coords `json:"cell"`
+	Levels int         `json:"levels"`
 }
 
-var attackHandlers = [...]func(g *game.Game, player game.Player) error{
-	DoAttackEasy, DoAttackMedium, DoAttackAPI, DoAttackAPI,
+// Strategy decides the moves of a bot.
+// A strategy only plans moves, the game is changed by DoAttack and DoUpgrade.
+// When the context is done, a strategy returns the best move it has found so far.
+type Strategy interface {
+	// PlanAttack returns the next attack of the player,
+	// or nil if the player should end the attack phase.
+	PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error)
+
+	// PlanUpgrade returns the next upgrade of the player,
+	// or nil if the player should end the turn.
+	PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error)
 }
 
-var upgradeHandlers = [...]func(g *game.Game, player game.Player) error{
-	DoUpgradeEasy, DoUpgradeMedium, DoUpgradeAPI, DoUpgradeAPI,
+// Budget limits the time a bot thinks. Zero values mean no limit.
+type Budget struct {
+	Move time.Duration // Time to plan a single move
+	Turn time.Duration // Time to plan all moves of a turn
 }
 
-func DoAttack(g *game.Game, player game.Player, difficulty int) error {
-	if difficulty > len(attackHandlers) {
-		return errIncorrectDifficulty
+// PlayTurn makes the whole turn of the player within the budget.
+// If the turn budget runs out, the remaining phases end without moves.
+func PlayTurn(ctx context.Context, g *game.Game, player game.Player, strategy Strategy, budget Budget) error {
+	if budget.Turn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget.Turn)
+		defer cancel()
+	}
+
+	err := DoAttack(ctx, g, player, strategy, budget.Move)
+
+	upgradeErr := DoUpgrade(ctx, g, player, strategy, budget.Move)
+	if err == nil {
+		err = upgradeErr
 	}
+	return err
+}
 
+// DoAttack makes attacks planned by the strategy until it stops, then ends the attack phase.
+// Every attack is planned within the move budget, unless it is zero.
+// If the context has a trace, the decisions are recorded to it.
+func DoAttack(ctx context.Context, g *game.Game, player game.Player, strategy Strategy, moveBudget time.Duration) error {
 	for !g.IsFinished() {
-		err := attackHandlers[difficulty](g, player)
-		if err != nil {
+		err := ctx.Err()
+
+		var attack *Attack
+		if err == nil {
+			moveCtx, cancel := withBudget(ctx, moveBudget)
+			moveCtx, decision := startDecision(moveCtx, g, player, PhaseAttack)
+			attack, err = strategy.PlanAttack(moveCtx, g, player)
+			decision.finish(attack, nil, err)
+			cancel()
+		}
+		if err == nil && attack != nil {
+			err = applyAttack(g, player, attack)
+		}
+		if err != nil || attack == nil {
 			g.EndAttack(player)
 			return err
 		}
@@ -49,14 +90,25 @@ func DoAttack(g *game.Game, player game.Player, difficulty int) error {
 	return nil
 }
 
-func DoUpgrade(g *game.Game, player game.Player, difficulty int) error {
-	if difficulty > len(upgradeHandlers) {
-		return errIncorrectDifficulty
-	}
-
+// DoUpgrade makes upgrades planned by the strategy until it stops, then ends the turn.
+// Every upgrade is planned within the move budget, unless it is zero.
+// If the context has a trace, the decisions are recorded to it.
+func DoUpgrade(ctx context.Context, g *game.Game, player game.Player, strategy Strategy, moveBudget time.Duration) error {
 	for !g.IsFinished() {
-		err := upgradeHandlers[difficulty](g, player)
-		if err != nil {
+		err := ctx.Err()
+
+		var upgrade *Upgrade
+		if err == nil {
+			moveCtx, cancel := withBudget(ctx, moveBudget)
+			moveCtx, decision := startDecision(moveCtx, g, player, PhaseUpgrade)
+			upgrade, err = strategy.PlanUpgrade(moveCtx, g, player)
+			decision.finish(nil, upgrade, err)
+			cancel()
+		}
+		if err == nil && upgrade != nil {
+			err = applyUpgrade(g, player, upgrade)
+		}
+		if err != nil || upgrade == nil {
 			g.EndTurn(player)
 			return err
 		}
@@ -64,240 +116,63 @@ func DoUpgrade(g *game.Game, player game.Player, difficulty int) error {
 	return nil
 }
 
-func getJSONResponse(g *game.Game, path string) ([]byte, error) {
-	// Powered by ChatGPT
-	jsonData := g.ToMap()
-
-	jsonBytes, err := json.Marshal(jsonData)
-	if err != nil {
-		return nil, err
-	}
-
-	url := "http://127.0.0.1:8000" + path // Replace with the actual URL of the FastAPI server
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBytes))
-	if err != nil {
-		return nil, err
-	}
-
-	// Set necessary headers
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+// withBudget returns the context limited by the budget, unless it is zero.
+func withBudget(ctx context.Context, budget time.Duration) (context.Context, context.CancelFunc) {
+	if budget <= 0 {
+		return context.WithCancel(ctx)
 	}
-	defer resp.Body.Close()
-
-	responseBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	return responseBody, nil
+	return context.WithTimeout(ctx, budget)
 }
 
-func DoAttackAPI(g *game.Game, player game.Player) error {
-	body, err := getJSONResponse(g, "/ai_attack")
+// applyAttack makes the planned attack in the game.
+func applyAttack(g *game.Game, player game.Player, attack *Attack) error {
+	from, err := g.Board.GetCell(attack.From)
 	if err != nil {
-		fmt.Println("Error for receiving JSON:", err)
 		return err
 	}
-
-	fmt.Println("Attack Response body:", string(body))
-
-	// Reading and unmarshaling JSON response
-	var action BotAction
-	err = json.Unmarshal(body, &action)
+	to, err := g.Board.GetCell(attack.To)
 	if err != nil {
-		fmt.Println("Error for unmarshaling JSON:", err)
 		return err
 	}
 
-	// If the bot returns None (skips the turn)
-	if action.Attack == nil {
-		return fmt.Errorf("no cells found for player %v", player.Id())
-	}
-
-	cell := action.Attack[0]
-	from := g.Board.Cells[cell[0]][cell[1]]
-
-	cell = action.Attack[1]
-	to := g.Board.Cells[cell[0]][cell[1]]
-
-	g.Attack(player, from, to)
-	return nil
+	return g.Attack(player, from, to)
 }
 
-func DoUpgradeAPI(g *game.Game, player game.Player) error {
-	body, err := getJSONResponse(g, "/ai_upgrade")
+// applyUpgrade makes the planned upgrade in the game.
+func applyUpgrade(g *game.Game, player game.Player, upgrade *Upgrade) error {
+	cell, err := g.Board.GetCell(upgrade.Cell)
 	if err != nil {
-		fmt.Println("Error for receiving JSON:", err)
 		return err
 	}
 
-	fmt.Println("Upgrade Response body:", string(body))
-
-	// Reading and unmarshaling JSON response
-	var action BotAction
-	err = json.Unmarshal(body, &action)
-	if err != nil {
-		fmt.Println("Error for unmarshaling JSON:", err)
-		return err
-	}
-
-	// If the bot returns None (skips the turn)
-	if action.Upgrade == nil {
-		return fmt.Errorf("no cells found for player %v", player.Id())
-	}
-
-	cell := action.Upgrade
-	cellToUpgrade := g.Board.Cells[cell[0]][cell[1]]
-
-	g.Upgrade(player, cellToUpgrade, 1)
-	return nil
+	return g.Upgrade(player, cell, upgrade.Levels)
 }
 
-func DoAttackMedium(g *game.Game, player game.Player) error {
-	var bestFrom, bestTo game.Cell
-	bestScore := 0
+// ownedCells returns all cells of the player.
+func ownedCells(g *game.Game, player game.Player) []game.Cell {
+	var cells []game.Cell
 	for _, row := range g.Board.Cells {
 		for _, cell := range row {
-			if cell == nil {
-				continue
-			}
-
-			if cell.Owner() == player && cell.Power() > 1 {
-				neighbors := cell.GetNeighbors(g.Board)
-				alienNeighbors := filter(neighbors, func(neighbor game.Cell) bool { return neighbor.Owner() != player })
-				for _, to := range alienNeighbors {
-					score := calculateScore(cell, to)
-					if bestScore == 0 || score > bestScore {
-						bestScore = score
-						bestFrom = cell
-						bestTo = to
-					}
-				}
+			if cell != nil && cell.Owner() == player {
+				cells = append(cells, cell)
 			}
 		}
 	}
-
-	if bestScore > 0 {
-		g.Attack(player, bestFrom, bestTo)
-		return nil
-	}
-	return fmt.Errorf("no cells found for player %v", player.Id())
+	return cells
 }
 
-func DoUpgradeMedium(g *game.Game, player game.Player) error {
-	if player.Points() == 0 {
-		return errNoPlayerCells(player.Id())
-	}
-
-	// ChatGPT powered
-	var ownedCells []game.Cell
-	for _, row := range g.Board.Cells {
-		for _, cell := range row {
-			if cell == nil {
-				continue
-			}
-			if cell.Owner() == player {
-				ownedCells = append(ownedCells, cell)
-			}
-		}
-	}
-
-	maxRow, maxCol := g.Board.Rows(), g.Board.Cols()
-	mainCell := [4][2]int{
-		{0, 0},
-		{maxRow, maxCol},
-		{maxRow, 0},
-		{0, maxCol},
-	}[player.Id()]
-
-	sort.Slice(ownedCells, func(i, j int) bool {
-		cellI, cellJ := ownedCells[i], ownedCells[j]
-		distanceI := math.Abs(float64(mainCell[0]-cellI.Row())) + math.Abs(float64(mainCell[1]-cellI.Col()))
-		distanceJ := math.Abs(float64(mainCell[0]-cellJ.Row())) + math.Abs(float64(mainCell[1]-cellJ.Col()))
-		if distanceI != distanceJ {
-			return distanceI > distanceJ
-		}
-		return cellI.Level() > cellJ.Level()
-	})
-
-	for _, cell := range ownedCells {
-		upgradeCost := cell.Level() * (cell.Level() + 1) / 2
-		if player.Points() >= upgradeCost {
-			return g.Upgrade(player, cell, 1)
-		}
-	}
-	return errNoPlayerCells(player.Id())
-}
-
-func calculateScore(from game.Cell, to game.Cell) int {
-	// Simple Evaluation Function implementation (temporary)
-
-	// If cell is free
-	if to.Owner() == nil {
-		return from.Power()
-	}
-
-	// If our cell power lower than other
-	if from.Power() <= to.Power() {
-		return 100 + (to.Power() - from.Power())
-	}
-
-	// Otherwise
-	return 10000 + (to.Power() - from.Power())
-}
-
-func DoAttackEasy(g *game.Game, player game.Player) error {
-	for _, row := range g.Board.Cells {
-		for _, cell := range row {
-			if cell == nil {
-				continue
-			}
-
-			if cell.Owner() == player && cell.Power() > 1 {
-				attackEasy(g, cell)
-			}
-		}
-	}
-	return errNoPlayerCells(player.Id())
+// upgradeCost returns the points needed to upgrade the cell by one level.
+func upgradeCost(cell game.Cell) int {
+	return cell.Level() * (cell.Level() + 1) / 2
 }
 
-func DoUpgradeEasy(g *game.Game, player game.Player) error {
-	if player.Points() == 0 {
-		return errNoPlayerCells(player.Id())
+// upgradeCostLevels returns the points needed to upgrade the cell by the given levels.
+func upgradeCostLevels(cell game.Cell, levels int) int {
+	cost := 0
+	for level := cell.Level(); level < cell.Level()+levels; level++ {
+		cost += level * (level + 1) / 2
 	}
-
-	// ChatGPT powered
-	var ownedCells []game.Cell
-	for _, row := range g.Board.Cells {
-		for _, cell := range row {
-			if cell == nil {
-				continue
-			}
-			if cell.Owner() == player {
-				ownedCells = append(ownedCells, cell)
-			}
-		}
-	}
-
-	for len(ownedCells) > 0 && player.Points() > 0 {
-		randomIndex := rand.Intn(len(ownedCells))
-		cellToUpgrade := ownedCells[randomIndex]
-
-		upgradeCost := (cellToUpgrade.Level() * (cellToUpgrade.Level() + 1)) / 2
-		if player.Points() >= upgradeCost {
-			err := g.Upgrade(player, cellToUpgrade, 1)
-			if err != nil {
-				return err
-			}
-		}
-		ownedCells = append(ownedCells[:randomIndex], ownedCells[randomIndex+1:]...)
-	}
-	return errNoPlayerCells(player.Id())
+	return cost
 }
 
 func filter[T any](ss []T, test func(T) bool) (ret []T) {
@@ -308,17 +183,3 @@ func filter[T any](ss []T, test func(T) bool) (ret []T) {
 	}
 	return
 }
-
-func attackEasy(g *game.Game, cell game.Cell) error {
-	player := cell.Owner()
-	neighbors := cell.GetNeighbors(g.Board)
-	alienNeighbors := filter(neighbors, func(neighbor game.Cell) bool { return neighbor.Owner() != player })
-	if len(alienNeighbors) > 0 {
-		to := alienNeighbors[rand.Intn(len(alienNeighbors))]
-		g.Attack(player, cell, to)
-		if to.Owner() == cell.Owner() {
-			return attackEasy(g, to)
-		}
-	}
-	return nil
-}
@@ -0,0 +1,150 @@
+package bot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	for _, info := range bot.List() {
+		_, err := bot.New(info.Name)
+		assert.NoError(t, err, info.Name)
+
+		if info.Level >= 0 {
+			_, err = bot.NewByLevel(info.Level)
+			assert.NoError(t, err, info.Name)
+
+			byLevel, err := bot.LookupLevel(info.Level)
+			assert.NoError(t, err, info.Name)
+			assert.Equal(t, info, byLevel)
+		}
+	}
+
+	_, err := bot.New("unknown")
+	assert.Error(t, err)
+
+	_, err = bot.NewByLevel(-1)
+	assert.Error(t, err)
+
+	_, err = bot.LookupLevel(-1)
+	assert.Error(t, err)
+
+	_, err = bot.NewByLevel(len(bot.List()) + 1)
+	assert.Error(t, err)
+}
+
+// playGame plays the game between the bots until it is finished.
+func playGame(t *testing.T, g *game.Game, strategies []bot.Strategy) {
+	for !g.IsFinished() {
+		player := g.Players[g.Turn()]
+		strategy := strategies[player.Id()]
+
+		require.NoError(t, bot.PlayTurn(context.Background(), g, player, strategy, bot.Budget{}))
+		require.NoError(t, g.CheckInvariants())
+	}
+}
+
+func TestPlayTurn_builtinBots(t *testing.T) {
+	for _, name := range []string{"random", "greedy", "planner", "chain"} {
+		t.Run(name, func(t *testing.T) {
+			g, err := game.NewGame(9, 9, 4, 1)
+			require.NoError(t, err)
+
+			strategies := make([]bot.Strategy, len(g.Players))
+			for i := range strategies {
+				strategies[i], err = bot.New(name)
+				require.NoError(t, err)
+			}
+
+			playGame(t, g, strategies)
+		})
+	}
+}
+
+func TestMCTS(t *testing.T) {
+	g, err := game.NewGame(7, 7, 2, 1)
+	require.NoError(t, err)
+
+	greedy, err := bot.New("greedy")
+	require.NoError(t, err)
+
+	mcts := bot.NewMCTS(bot.WithIterations(20), bot.WithPlayoutTurns(2), bot.WithPlayout("random"))
+
+	playGame(t, g, []bot.Strategy{mcts, greedy})
+}
+
+func TestConfigureMCTS(t *testing.T) {
+	t.Cleanup(func() { bot.ConfigureMCTS() })
+
+	g, err := game.NewGame(7, 7, 2, 1)
+	require.NoError(t, err)
+
+	// The registered bot searches with the configured options
+	bot.ConfigureMCTS(bot.WithIterations(1), bot.WithPlayout("unknown"))
+	mcts, err := bot.New("mcts")
+	require.NoError(t, err)
+	_, err = mcts.PlanAttack(context.Background(), g, g.Players[0])
+	assert.Error(t, err)
+
+	bot.ConfigureMCTS()
+	mcts, err = bot.New("mcts")
+	require.NoError(t, err)
+	_, err = mcts.PlanAttack(context.Background(), g, g.Players[0])
+	assert.NoError(t, err)
+}
+
+func TestAlphaBeta_deterministic(t *testing.T) {
+	var results []map[string]interface{}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		g, err := game.NewGame(7, 7, 2, 1)
+		require.NoError(t, err)
+
+		strategies := []bot.Strategy{bot.NewAlphaBeta(bot.WithDepth(2)), bot.NewAlphaBeta(bot.WithDepth(1))}
+		for turn := 0; turn < 20 && !g.IsFinished(); turn++ {
+			player := g.Players[g.Turn()]
+			require.NoError(t, bot.PlayTurn(context.Background(), g, player, strategies[player.Id()], bot.Budget{}))
+			require.NoError(t, g.CheckInvariants())
+		}
+
+		results = append(results, g.ToMap())
+	}
+
+	assert.Equal(t, results[0], results[1])
+}
+
+func TestAlphaBeta_manyPlayers(t *testing.T) {
+	g, err := game.NewGame(7, 7, 3, 1)
+	require.NoError(t, err)
+
+	strategies := []bot.Strategy{bot.NewAlphaBeta(), bot.NewAlphaBeta(), bot.NewAlphaBeta()}
+	playGame(t, g, strategies)
+}
+
+func TestPlayTurn_budget(t *testing.T) {
+	g, err := game.NewGame(9, 9, 2, 1)
+	require.NoError(t, err)
+
+	slow := []bot.Strategy{
+		bot.NewMCTS(bot.WithIterations(1e9), bot.WithTimeBudget(time.Hour)),
+		bot.NewAlphaBeta(bot.WithDepth(100)),
+	}
+
+	for turn := 0; turn < 10 && !g.IsFinished(); turn++ {
+		player := g.Players[g.Turn()]
+
+		start := time.Now()
+		budget := bot.Budget{Move: 5 * time.Millisecond, Turn: 50 * time.Millisecond}
+		bot.PlayTurn(context.Background(), g, player, slow[player.Id()], budget)
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.NotEqual(t, player.Id(), g.Turn())
+		require.NoError(t, g.CheckInvariants())
+	}
+}
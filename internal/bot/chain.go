@@ -0,0 +1,199 @@
+package bot
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+func init() {
+	Register(Info{
+		Name:        "chain",
+		Description: "Searches the best sequence of attacks of its turn and spends its points on its frontier",
+		Level:       -1,
+	}, func() Strategy { return WithUpgradePlanner(&chainStrategy{planner: NewChainPlanner()}) })
+}
+
+// ChainPlanner searches the sequences of attacks within one turn by beam search on copies of the game.
+// A captured cell keeps the rest of the attacking power, so it may attack further,
+// and the order of attacks matters.
+type ChainPlanner struct {
+	BeamWidth int // Count of the best sequences kept after every attack
+	MaxDepth  int // Max count of attacks in a sequence
+
+	// Weights of the resulting position
+	Captures     float64 // Per cell captured by the sequence
+	Eliminations float64 // Per opponent left without cells
+	Safety       float64 // Per cell of the player that no neighbor can capture on the next turn
+}
+
+// NewChainPlanner returns the planner with the default weights.
+func NewChainPlanner() ChainPlanner {
+	return ChainPlanner{
+		BeamWidth:    8,
+		MaxDepth:     12,
+		Captures:     10,
+		Eliminations: 50,
+		Safety:       1,
+	}
+}
+
+// chainNode is a sequence of attacks and the game after it.
+type chainNode struct {
+	attacks []Attack
+	result  *game.Game
+	score   float64
+}
+
+// Plan returns the sequence of attacks with the best resulting position, nil if no attacks are better.
+// When the context is done, the best sequence found so far is returned.
+func (p ChainPlanner) Plan(ctx context.Context, g *game.Game, player game.Player) []Attack {
+	if g.IsFinished() || g.Turn() != player.Id() {
+		return nil
+	}
+
+	root := chainNode{result: g}
+	root.score = p.score(g, root.result, player)
+	best := root
+
+	beam := []chainNode{root}
+	for depth := 0; depth < p.MaxDepth && len(beam) > 0 && ctx.Err() == nil; depth++ {
+		var next []chainNode
+		seen := make(map[uint64]bool)
+		for _, node := range beam {
+			if node.result.IsFinished() {
+				continue
+			}
+
+			me := node.result.Players[player.Id()]
+			for _, attack := range legalAttacks(node.result, me) {
+				result := node.result.Clone()
+				if applyAttack(result, result.Players[player.Id()], &attack) != nil {
+					continue
+				}
+
+				// Different orders of the same attacks often lead to the same position
+				hash := positionHash(result, true)
+				if seen[hash] {
+					continue
+				}
+				seen[hash] = true
+
+				attacks := make([]Attack, len(node.attacks), len(node.attacks)+1)
+				copy(attacks, node.attacks)
+				next = append(next, chainNode{
+					attacks: append(attacks, attack),
+					result:  result,
+					score:   p.score(g, result, player),
+				})
+			}
+		}
+
+		// The first attacks are the candidates of the traced decision
+		if depth == 0 {
+			for _, node := range next {
+				considerAttack(ctx, node.attacks[0], node.score)
+			}
+		}
+
+		sort.SliceStable(next, func(i, j int) bool { return next[i].score > next[j].score })
+		if len(next) > p.BeamWidth {
+			next = next[:p.BeamWidth]
+		}
+		if len(next) > 0 && next[0].score > best.score {
+			best = next[0]
+		}
+		beam = next
+	}
+	return best.attacks
+}
+
+// score returns the value of the position after the attacks for the player.
+func (p ChainPlanner) score(before, after *game.Game, player game.Player) float64 {
+	if winner := after.Winner(); winner != nil && winner.Id() == player.Id() {
+		return winScore
+	}
+
+	me := after.Players[player.Id()]
+	score := p.Captures * float64(me.CellsCount()-before.Players[player.Id()].CellsCount())
+
+	for i, opponent := range after.Players {
+		if i != player.Id() && opponent.CellsCount() == 0 && before.Players[i].CellsCount() > 0 {
+			score += p.Eliminations
+		}
+	}
+
+	for _, cell := range ownedCells(after, me) {
+		if !isCapturable(after, cell) {
+			score += p.Safety
+		}
+	}
+	return score
+}
+
+// isCapturable reports whether a neighbor of another owner can capture the cell on the next turn,
+// when the power of the cell is recalculated from its neighbors at the end of the turn.
+func isCapturable(g *game.Game, cell game.Cell) bool {
+	neighbors := cell.GetNeighbors(g.Board)
+
+	power := 1
+	for _, neighbor := range neighbors {
+		if neighbor.Owner() == cell.Owner() {
+			power += neighbor.Level() - 1
+		}
+	}
+
+	for _, neighbor := range neighbors {
+		if neighbor.Owner() != nil && neighbor.Owner() != cell.Owner() && neighbor.Power() > power {
+			return true
+		}
+	}
+	return false
+}
+
+// chainStrategy makes the attacks of the best sequence found by its planner.
+type chainStrategy struct {
+	planner ChainPlanner
+	steps   []planStep // Rest of the planned attacks
+}
+
+// PlanAttack returns the next attack of the planned sequence.
+// If the game has gone off the plan, the sequence is searched again.
+func (s *chainStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	hash := positionHash(g, true)
+	if len(s.steps) == 0 || s.steps[0].hash != hash {
+		s.steps = s.plan(ctx, g, player)
+	}
+	if len(s.steps) == 0 {
+		return nil, nil
+	}
+
+	step := s.steps[0]
+	s.steps = s.steps[1:]
+	return step.attack, nil
+}
+
+// plan searches the sequence of attacks and returns its steps.
+func (s *chainStrategy) plan(ctx context.Context, g *game.Game, player game.Player) []planStep {
+	attacks := s.planner.Plan(ctx, g, player)
+	if len(attacks) == 0 {
+		return nil
+	}
+
+	result := g.Clone()
+	me := result.Players[player.Id()]
+	steps := make([]planStep, 0, len(attacks))
+	for i := range attacks {
+		steps = append(steps, planStep{hash: positionHash(result, true), attack: &attacks[i]})
+		if applyAttack(result, me, &attacks[i]) != nil {
+			break
+		}
+	}
+	return steps
+}
+
+// PlanUpgrade upgrades the cells the way the greedy bot does.
+func (s *chainStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	return greedyStrategy{}.PlanUpgrade(ctx, g, player)
+}
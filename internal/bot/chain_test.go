@@ -0,0 +1,59 @@
+package bot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainPlanner(t *testing.T) {
+	g, err := game.TestGameAttack()
+	require.NoError(t, err)
+	player := g.Players[0]
+	before := player.CellsCount()
+
+	attacks := bot.NewChainPlanner().Plan(context.Background(), g, player)
+	require.NotEmpty(t, attacks)
+
+	// The planned attacks are legal one after another and capture cells
+	for _, attack := range attacks {
+		from, err := g.Board.GetCell(attack.From)
+		require.NoError(t, err)
+		to, err := g.Board.GetCell(attack.To)
+		require.NoError(t, err)
+		require.NoError(t, g.Attack(player, from, to))
+	}
+	assert.Greater(t, player.CellsCount(), before)
+}
+
+func TestChainPlanner_beatsGreedyAttacks(t *testing.T) {
+	g, err := game.TestGameAttack()
+	require.NoError(t, err)
+	player := g.Players[0]
+
+	chain := g.Clone()
+	for _, attack := range bot.NewChainPlanner().Plan(context.Background(), chain, chain.Players[0]) {
+		from, _ := chain.Board.GetCell(attack.From)
+		to, _ := chain.Board.GetCell(attack.To)
+		require.NoError(t, chain.Attack(chain.Players[0], from, to))
+	}
+
+	greedy, err := bot.New("greedy")
+	require.NoError(t, err)
+	require.NoError(t, bot.DoAttack(context.Background(), g, player, greedy, 0))
+
+	assert.GreaterOrEqual(t, chain.Players[0].CellsCount(), player.CellsCount())
+}
+
+func TestChainPlanner_cancelled(t *testing.T) {
+	g, err := game.TestGameAttack()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Empty(t, bot.NewChainPlanner().Plan(ctx, g, g.Players[0]))
+}
@@ -0,0 +1,14 @@
+package bot
+
+import "github.com/Vacym/neighbors-force/internal/game"
+
+// AcceptsDraw reports whether the bot of the player agrees to finish the game in a draw.
+// The bot agrees unless it leads the game, i.e. owns more cells than every other player.
+func AcceptsDraw(g *game.Game, player game.Player) bool {
+	for _, p := range g.Players {
+		if p != player && p.CellsCount() >= player.CellsCount() {
+			return true
+		}
+	}
+	return false
+}
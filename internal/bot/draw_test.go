@@ -0,0 +1,23 @@
+package bot_test
+
+import (
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptsDraw(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 3)
+	require.NoError(t, err)
+
+	// Nobody leads
+	assert.True(t, bot.AcceptsDraw(g, g.Players[1]))
+
+	// The leader declines, the others agree
+	require.NoError(t, g.SetHandicap(1, game.Handicap{Cells: 2}))
+	assert.False(t, bot.AcceptsDraw(g, g.Players[1]))
+	assert.True(t, bot.AcceptsDraw(g, g.Players[2]))
+}
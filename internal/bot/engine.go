@@ -0,0 +1,469 @@
+package bot
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+var (
+	errEngineExited    = errors.New("engine has exited")
+	errEngineTimeout   = errors.New("engine has not answered in time")
+	errEngineRestarts  = errors.New("engine has crashed too many times")
+	errEngineMalformed = errors.New("malformed answer of the engine")
+)
+
+// stderrLimit is the count of the last bytes of the engine's stderr that are kept.
+const stderrLimit = 4 << 10
+
+// engineStrategy asks a bot running as a child process for the moves.
+// The engine speaks the line-based protocol described in docs/engine-protocol.md.
+// If the engine fails or suggests an illegal move, the move is planned by the fallback bot.
+type engineStrategy struct {
+	path         string        // Path of the engine executable
+	args         []string      // Arguments of the engine
+	startTimeout time.Duration // Max time of the handshake
+	moveTimeout  time.Duration // Max time of a single move
+	maxRestarts  int           // Max count of restarts after the engine fails
+	fallback     string        // Name of the bot used when the engine fails, empty for none
+	launcher     launcher      // Starts and kills the engine processes
+
+	process  *engineProcess
+	failures int // Count of times in a row the engine has crashed or hung
+}
+
+// EngineOption configures the engine bot.
+type EngineOption func(*engineStrategy)
+
+// NewEngine creates a bot that runs the executable at the path with the arguments.
+// The process is started at the first move and stopped by Close.
+func NewEngine(path string, args []string, options ...EngineOption) Strategy {
+	s := &engineStrategy{
+		path:         path,
+		args:         args,
+		startTimeout: 5 * time.Second,
+		moveTimeout:  2 * time.Second,
+		maxRestarts:  3,
+		fallback:     "greedy",
+		launcher:     plainLauncher{},
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// WithStartTimeout sets the max time the engine may take to answer the handshake.
+func WithStartTimeout(timeout time.Duration) EngineOption {
+	return func(s *engineStrategy) {
+		s.startTimeout = timeout
+	}
+}
+
+// WithMoveTimeout sets the max time the engine may think over a single move.
+func WithMoveTimeout(timeout time.Duration) EngineOption {
+	return func(s *engineStrategy) {
+		s.moveTimeout = timeout
+	}
+}
+
+// WithMaxRestarts sets the max count of restarts after the engine crashes or hangs.
+func WithMaxRestarts(restarts int) EngineOption {
+	return func(s *engineStrategy) {
+		s.maxRestarts = restarts
+	}
+}
+
+// WithEngineFallback sets the name of the bot that plans the moves when the engine fails.
+// An empty name disables the fallback, so failures end the phase.
+func WithEngineFallback(name string) EngineOption {
+	return func(s *engineStrategy) {
+		s.fallback = name
+	}
+}
+
+// PlanAttack asks the engine for the next attack.
+func (s *engineStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	attack, _, err := s.requestMove(ctx, g, player, PhaseAttack)
+	if err == nil && attack != nil {
+		err = checkAttack(g, player, *attack)
+	}
+	if err != nil {
+		fallback, fallbackErr := s.fallbackStrategy()
+		if fallbackErr != nil {
+			return nil, err
+		}
+		return fallback.PlanAttack(ctx, g, player)
+	}
+	return attack, nil
+}
+
+// PlanUpgrade asks the engine for the next upgrade.
+func (s *engineStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	_, upgrade, err := s.requestMove(ctx, g, player, PhaseUpgrade)
+	if err == nil && upgrade != nil {
+		err = checkUpgrade(g, player, *upgrade)
+	}
+	if err != nil {
+		fallback, fallbackErr := s.fallbackStrategy()
+		if fallbackErr != nil {
+			return nil, err
+		}
+		return fallback.PlanUpgrade(ctx, g, player)
+	}
+	return upgrade, nil
+}
+
+// Close stops the engine process.
+func (s *engineStrategy) Close() error {
+	if s.process == nil {
+		return nil
+	}
+
+	s.process.stop()
+	s.process = nil
+	return nil
+}
+
+// fail stops the engine that has crashed or hung, so that it is restarted at the next move.
+func (s *engineStrategy) fail() {
+	s.Close()
+	s.failures++
+}
+
+// fallbackStrategy creates the bot that plans the moves when the engine fails.
+func (s *engineStrategy) fallbackStrategy() (Strategy, error) {
+	if s.fallback == "" {
+		return nil, errUnknownBot
+	}
+	return New(s.fallback)
+}
+
+// requestMove sends the position to the engine and waits for its best move.
+// An engine that crashes or does not answer in time is stopped and restarted at the next move.
+func (s *engineStrategy) requestMove(ctx context.Context, g *game.Game, player game.Player, phase Phase) (*Attack, *Upgrade, error) {
+	if err := s.ensureStarted(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	if s.moveTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.moveTimeout)
+		defer cancel()
+	}
+
+	state, err := json.Marshal(NewState(g, player, phase))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	moveTime := s.moveTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		moveTime = time.Until(deadline)
+	}
+	if moveTime < time.Millisecond {
+		moveTime = time.Millisecond
+	}
+
+	err = s.process.send("position "+string(state), fmt.Sprintf("go movetime %d", moveTime.Milliseconds()))
+	if err != nil {
+		s.fail()
+		return nil, nil, err
+	}
+
+	// An engine that answers late would be out of sync, so it is restarted
+	line, err := s.process.readUntil(ctx, "bestmove")
+	if err != nil {
+		s.fail()
+		return nil, nil, err
+	}
+
+	// The engine has recovered, so earlier failures do not count against it
+	s.failures = 0
+	return parseBestMove(line, phase)
+}
+
+// ensureStarted starts the engine and makes the handshake, unless it is already running.
+func (s *engineStrategy) ensureStarted(ctx context.Context) error {
+	if s.process != nil && !s.process.exited() {
+		return nil
+	}
+
+	if s.process != nil {
+		s.fail()
+	}
+	if s.failures > s.maxRestarts {
+		return errEngineRestarts
+	}
+
+	process, err := startEngine(s.launcher, s.path, s.args)
+	if err != nil {
+		s.failures++
+		return err
+	}
+	s.process = process
+
+	if s.startTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.startTimeout)
+		defer cancel()
+	}
+
+	if err := process.send("nfe"); err != nil {
+		s.fail()
+		return err
+	}
+	if _, err := process.readUntil(ctx, "nfeok"); err != nil {
+		s.fail()
+		return err
+	}
+	return nil
+}
+
+// parseBestMove parses the "bestmove" answer of the engine.
+func parseBestMove(line string, phase Phase) (*Attack, *Upgrade, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return nil, nil, fmt.Errorf("%w: %q", errEngineMalformed, line)
+	}
+
+	numbers := make([]int, len(fields)-2)
+	for i, field := range fields[2:] {
+		number, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %q", errEngineMalformed, line)
+		}
+		numbers[i] = number
+	}
+
+	switch {
+	case fields[1] == "none" && len(numbers) == 0:
+		return nil, nil, nil
+	case fields[1] == "attack" && phase == PhaseAttack && len(numbers) == 4:
+		return &Attack{
+			From: game.Coords{Row: numbers[0], Col: numbers[1]},
+			To:   game.Coords{Row: numbers[2], Col: numbers[3]},
+		}, nil, nil
+	case fields[1] == "upgrade" && phase == PhaseUpgrade && len(numbers) == 3:
+		return nil, &Upgrade{
+			Cell:   game.Coords{Row: numbers[0], Col: numbers[1]},
+			Levels: numbers[2],
+		}, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %q", errEngineMalformed, line)
+}
+
+// launcher starts and kills the engine processes.
+type launcher interface {
+	// command prepares the process of the engine.
+	command(path string, args []string) (*exec.Cmd, error)
+
+	// kill kills the process of the engine.
+	kill(cmd *exec.Cmd) error
+
+	// cleanup releases the resources of the process after it has exited.
+	cleanup(cmd *exec.Cmd)
+
+	// outputLimit returns the max count of bytes the engine may write to stdout, 0 means no limit.
+	outputLimit() int64
+}
+
+// plainLauncher runs the engine as an ordinary child process.
+type plainLauncher struct{}
+
+// command prepares the process of the engine.
+func (plainLauncher) command(path string, args []string) (*exec.Cmd, error) {
+	return exec.Command(path, args...), nil
+}
+
+// kill kills the process of the engine.
+func (plainLauncher) kill(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// cleanup releases the resources of the process after it has exited.
+func (plainLauncher) cleanup(cmd *exec.Cmd) {}
+
+// outputLimit returns the max count of bytes the engine may write to stdout.
+func (plainLauncher) outputLimit() int64 {
+	return 0
+}
+
+// engineProcess is a running engine.
+type engineProcess struct {
+	launcher launcher
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	lines    chan string   // Lines of the engine's stdout, closed when it is over
+	closing  chan struct{} // Closed when the output is not read anymore
+	done     chan struct{} // Closed when the process has exited
+	stderr   *tailBuffer   // Last bytes of the engine's stderr
+}
+
+// startEngine starts the engine process and reads its output in the background.
+// Output over the limit of the launcher is treated as the end of it.
+func startEngine(l launcher, path string, args []string) (*engineProcess, error) {
+	cmd, err := l.command(path, args)
+	if err != nil {
+		return nil, err
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		l.cleanup(cmd)
+		return nil, err
+	}
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		l.cleanup(cmd)
+		return nil, err
+	}
+	stderr := &tailBuffer{limit: stderrLimit}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		l.cleanup(cmd)
+		return nil, err
+	}
+
+	var stdout io.Reader = stdoutPipe
+	if limit := l.outputLimit(); limit > 0 {
+		stdout = io.LimitReader(stdoutPipe, limit)
+	}
+
+	p := &engineProcess{
+		launcher: l,
+		cmd:      cmd,
+		stdin:    stdin,
+		lines:    make(chan string, 64),
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+		stderr:   stderr,
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64<<10), maxResponseSize)
+		for scanner.Scan() {
+			select {
+			case p.lines <- strings.TrimSpace(scanner.Text()):
+			case <-p.closing:
+			}
+		}
+		close(p.lines)
+
+		// An engine without output is useless, even if it still runs
+		l.kill(cmd)
+		cmd.Wait()
+		l.cleanup(cmd)
+		close(p.done)
+	}()
+
+	return p, nil
+}
+
+// send writes the commands to the engine, one per line.
+func (p *engineProcess) send(commands ...string) error {
+	for _, command := range commands {
+		if _, err := io.WriteString(p.stdin, command+"\n"); err != nil {
+			return p.exitError()
+		}
+	}
+	return nil
+}
+
+// readUntil returns the first line of the engine's output that starts with the prefix.
+// The other lines, e.g. "info", are skipped.
+func (p *engineProcess) readUntil(ctx context.Context, prefix string) (string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return "", errEngineTimeout
+		case line, ok := <-p.lines:
+			if !ok {
+				return "", p.exitError()
+			}
+			if strings.HasPrefix(line, prefix) {
+				return line, nil
+			}
+		}
+	}
+}
+
+// exited reports whether the process has exited.
+func (p *engineProcess) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// exitError describes the exit of the engine with the end of its stderr.
+// It waits for the process to exit, so that the whole stderr is captured.
+func (p *engineProcess) exitError() error {
+	p.stdin.Close()
+	<-p.done
+
+	if tail := p.stderr.String(); tail != "" {
+		return fmt.Errorf("%w: %s", errEngineExited, tail)
+	}
+	return errEngineExited
+}
+
+// stop asks the engine to quit and kills it if it does not.
+func (p *engineProcess) stop() {
+	close(p.closing)
+	io.WriteString(p.stdin, "quit\n")
+	p.stdin.Close()
+
+	select {
+	case <-p.done:
+		return
+	case <-time.After(time.Second):
+	}
+
+	// The engine may exit by itself meanwhile, so the error is of no interest
+	p.launcher.kill(p.cmd)
+	<-p.done
+}
+
+// tailBuffer keeps the last bytes written to it.
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	data  []byte
+}
+
+// Write appends the bytes and drops the oldest ones over the limit.
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data = append(b.data, p...)
+	if len(b.data) > b.limit {
+		b.data = b.data[len(b.data)-b.limit:]
+	}
+	return len(p), nil
+}
+
+// String returns the kept bytes without surrounding spaces.
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return strings.TrimSpace(string(b.data))
+}
@@ -0,0 +1,189 @@
+package bot_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// engineArg is the first argument of the test binary started as an engine,
+// the second one selects its behavior.
+const engineArg = "-test-engine"
+
+func TestMain(m *testing.M) {
+	if len(os.Args) == 3 && os.Args[1] == engineArg {
+		runTestEngine(os.Args[2])
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestEngine is a stand-in engine that answers every move in the given mode.
+func runTestEngine(mode string) {
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	moves := 0
+	for scanner.Scan() {
+		switch scanner.Text() {
+		case "nfe":
+			fmt.Println("id name test")
+			fmt.Println("nfeok")
+		case "quit":
+			return
+		default:
+			if len(scanner.Text()) < 2 || scanner.Text()[:2] != "go" {
+				continue
+			}
+			moves++
+
+			switch mode {
+			case "attack":
+				fmt.Println("info thinking")
+				fmt.Println("bestmove attack 0 0 0 1")
+			case "none":
+				fmt.Println("bestmove none")
+			case "garbage":
+				fmt.Println("bestmove attack zero")
+			case "crash":
+				fmt.Fprintln(os.Stderr, "engine crashed")
+				os.Exit(1)
+			case "hang":
+				time.Sleep(time.Minute)
+			case "flaky":
+				if moves%2 == 0 {
+					os.Exit(1)
+				}
+				fmt.Println("bestmove none")
+			default:
+				runSandboxedTestEngine(mode)
+			}
+		}
+	}
+}
+
+// newTestEngine creates the engine bot running the test binary in the mode.
+func newTestEngine(t *testing.T, mode string, options ...bot.EngineOption) bot.Strategy {
+	executable, err := os.Executable()
+	require.NoError(t, err)
+
+	strategy := bot.NewEngine(executable, []string{engineArg, mode}, options...)
+	t.Cleanup(func() { strategy.(io.Closer).Close() })
+	return strategy
+}
+
+func TestEngine_moves(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+	player := g.Players[0]
+
+	strategy := newTestEngine(t, "attack", bot.WithEngineFallback(""))
+
+	attack, err := strategy.PlanAttack(context.Background(), g, player)
+	require.NoError(t, err)
+	assert.Equal(t, &bot.Attack{From: game.Coords{Row: 0, Col: 0}, To: game.Coords{Row: 0, Col: 1}}, attack)
+
+	// The same process answers the next moves
+	attack, err = strategy.PlanAttack(context.Background(), g, player)
+	require.NoError(t, err)
+	assert.NotNil(t, attack)
+
+	require.NoError(t, strategy.(io.Closer).Close())
+}
+
+func TestEngine_playTurn(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	strategy := newTestEngine(t, "none", bot.WithEngineFallback(""))
+	require.NoError(t, bot.PlayTurn(context.Background(), g, g.Players[0], strategy, bot.Budget{}))
+	assert.Equal(t, 1, g.Turn())
+}
+
+func TestEngine_failures(t *testing.T) {
+	for _, mode := range []string{"garbage", "crash", "hang"} {
+		mode := mode
+		t.Run(mode, func(t *testing.T) {
+			g, err := game.NewCompleteBoardGame(5, 5, 2)
+			require.NoError(t, err)
+			player := g.Players[0]
+
+			// Without a fallback the phase ends with an error
+			strategy := newTestEngine(t, mode,
+				bot.WithEngineFallback(""),
+				bot.WithMoveTimeout(50*time.Millisecond),
+			)
+			start := time.Now()
+			attack, err := strategy.PlanAttack(context.Background(), g, player)
+			assert.Error(t, err)
+			assert.Nil(t, attack)
+			assert.Less(t, time.Since(start), 5*time.Second)
+
+			// The fallback bot plans the move instead, the engine is restarted
+			strategy = newTestEngine(t, mode, bot.WithMoveTimeout(50*time.Millisecond))
+			attack, err = strategy.PlanAttack(context.Background(), g, player)
+			assert.NoError(t, err)
+			assert.NotNil(t, attack)
+			attack, err = strategy.PlanAttack(context.Background(), g, player)
+			assert.NoError(t, err)
+			assert.NotNil(t, attack)
+		})
+	}
+}
+
+func TestEngine_stderr(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	strategy := newTestEngine(t, "crash", bot.WithEngineFallback(""))
+	_, err = strategy.PlanAttack(context.Background(), g, g.Players[0])
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "engine crashed")
+}
+
+func TestEngine_maxRestarts(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	strategy := newTestEngine(t, "crash", bot.WithEngineFallback(""), bot.WithMaxRestarts(1))
+	for i := 0; i < 3; i++ {
+		_, err = strategy.PlanAttack(context.Background(), g, g.Players[0])
+		require.Error(t, err)
+	}
+	assert.Contains(t, err.Error(), "too many times")
+}
+
+func TestEngine_recovered(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	// Every restarted engine answers once before it crashes, so the failures do not add up
+	strategy := newTestEngine(t, "flaky", bot.WithEngineFallback(""), bot.WithMaxRestarts(1))
+	for i := 0; i < 6; i++ {
+		_, err = strategy.PlanAttack(context.Background(), g, g.Players[0])
+		if i%2 == 0 {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+			assert.NotContains(t, err.Error(), "too many times")
+		}
+	}
+}
+
+func TestEngine_missingExecutable(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	strategy := bot.NewEngine("/nonexistent/engine", nil, bot.WithEngineFallback("greedy"))
+	attack, err := strategy.PlanAttack(context.Background(), g, g.Players[0])
+	assert.NoError(t, err)
+	assert.NotNil(t, attack)
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+func init() {
+	Register(Info{
+		Name:        "greedy",
+		Description: "Makes the best scored attack and upgrades cells far from its corner",
+		Level:       1,
+	}, func() Strategy { return greedyStrategy{} })
+}
+
+// greedyStrategy makes the best move by a simple evaluation of one move ahead.
+type greedyStrategy struct{}
+
+// PlanAttack returns the attack with the best score.
+func (greedyStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	var bestFrom, bestTo game.Cell
+	bestScore := 0
+	for _, cell := range ownedCells(g, player) {
+		if cell.Power() <= 1 {
+			continue
+		}
+
+		neighbors := cell.GetNeighbors(g.Board)
+		alienNeighbors := filter(neighbors, func(neighbor game.Cell) bool { return neighbor.Owner() != player })
+		for _, to := range alienNeighbors {
+			score := calculateScore(cell, to)
+			considerAttack(ctx, Attack{From: cell.Coords(), To: to.Coords()}, float64(score))
+			if bestScore == 0 || score > bestScore {
+				bestScore = score
+				bestFrom = cell
+				bestTo = to
+			}
+		}
+	}
+
+	if bestScore > 0 {
+		return &Attack{From: bestFrom.Coords(), To: bestTo.Coords()}, nil
+	}
+	return nil, nil
+}
+
+// PlanUpgrade upgrades the affordable cell farthest from the player's corner.
+func (greedyStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	if player.Points() == 0 {
+		return nil, nil
+	}
+
+	cells := ownedCells(g, player)
+
+	maxRow, maxCol := g.Board.Rows(), g.Board.Cols()
+	mainCell := [4][2]int{
+		{0, 0},
+		{maxRow, maxCol},
+		{maxRow, 0},
+		{0, maxCol},
+	}[player.Id()]
+
+	distance := func(cell game.Cell) float64 {
+		return math.Abs(float64(mainCell[0]-cell.Row())) + math.Abs(float64(mainCell[1]-cell.Col()))
+	}
+
+	sort.Slice(cells, func(i, j int) bool {
+		cellI, cellJ := cells[i], cells[j]
+		distanceI, distanceJ := distance(cellI), distance(cellJ)
+		if distanceI != distanceJ {
+			return distanceI > distanceJ
+		}
+		return cellI.Level() > cellJ.Level()
+	})
+
+	var best *Upgrade
+	for _, cell := range cells {
+		if player.Points() >= upgradeCost(cell) {
+			upgrade := Upgrade{Cell: cell.Coords(), Levels: 1}
+			considerUpgrade(ctx, upgrade, distance(cell))
+			if best == nil {
+				best = &upgrade
+			}
+		}
+	}
+	return best, nil
+}
+
+func calculateScore(from game.Cell, to game.Cell) int {
+	// Simple Evaluation Function implementation (temporary)
+
+	// If cell is free
+	if to.Owner() == nil {
+		return from.Power()
+	}
+
+	// If our cell power lower than other
+	if from.Power() <= to.Power() {
+		return 100 + (to.Power() - from.Power())
+	}
+
+	// Otherwise
+	return 10000 + (to.Power() - from.Power())
+}
@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+// positionHash returns the hash of everything that matters for the next moves:
+// the size of the board, the cells, the points and the income of the players, whose turn it is and its phase.
+// The hash is not canonical: mirrored positions have different hashes.
+func positionHash(g *game.Game, attacking bool) uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 0, 16)
+
+	write := func(values ...int) {
+		buf = buf[:0]
+		for _, v := range values {
+			buf = binary.AppendVarint(buf, int64(v))
+		}
+		h.Write(buf)
+	}
+
+	phase := 0
+	if attacking {
+		phase = 1
+	}
+	write(g.Board.Rows(), g.Board.Cols(), g.Turn(), phase)
+
+	for _, p := range g.Players {
+		income := p.Handicap().Income
+		if income == 0 {
+			income = 1
+		}
+		write(p.Points(), p.CellsCount(), int(math.Float64bits(income)))
+	}
+
+	for _, row := range g.Board.Cells {
+		for _, cell := range row {
+			if cell == nil {
+				write(-1)
+				continue
+			}
+
+			owner := -1
+			if cell.Owner() != nil {
+				owner = cell.Owner().Id()
+			}
+			write(owner, cell.Level(), cell.Power())
+		}
+	}
+
+	return h.Sum64()
+}
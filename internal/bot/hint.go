@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+// Reasons of the suggested moves.
+const (
+	ReasonCaptures        = "captures"         // The attack captures the cell
+	ReasonWeakens         = "weakens"          // The attack only lowers the power of the cell
+	ReasonBoostsNeighbors = "boosts_neighbors" // The upgrade gives power to the neighbors of the same owner
+	ReasonRaisesLevel     = "raises_level"     // The upgrade only raises the level of a lone cell
+	ReasonEndAttack       = "end_attack"       // No attack is worth making
+	ReasonEndTurn         = "end_turn"         // No upgrade is worth making
+)
+
+// Hint is a move suggested to the player.
+// Both moves are nil when the player should end the current phase.
+type Hint struct {
+	Attack  *Attack  `json:"attack,omitempty"`
+	Upgrade *Upgrade `json:"upgrade,omitempty"`
+	Reason  string   `json:"reason"` // Machine-readable reason, one of the Reason constants
+}
+
+// Suggest returns the next move the strategy would make for the player in the current phase.
+// The strategy plans on a copy of the game, so the game is not changed.
+func Suggest(ctx context.Context, g *game.Game, player game.Player, strategy Strategy) (Hint, error) {
+	if g.IsFinished() || g.Turn() != player.Id() {
+		return Hint{}, errNotPlayerTurn
+	}
+
+	clone := g.Clone()
+	me := clone.Players[player.Id()]
+
+	if player.Attacking() {
+		attack, err := strategy.PlanAttack(ctx, clone, me)
+		if err != nil {
+			return Hint{}, err
+		}
+		if attack == nil {
+			return Hint{Reason: ReasonEndAttack}, nil
+		}
+		if err := checkAttack(g, player, *attack); err != nil {
+			return Hint{}, err
+		}
+
+		from, _ := g.Board.GetCell(attack.From)
+		to, _ := g.Board.GetCell(attack.To)
+		reason := ReasonWeakens
+		if from.Power() > to.Power() {
+			reason = ReasonCaptures
+		}
+		return Hint{Attack: attack, Reason: reason}, nil
+	}
+
+	upgrade, err := strategy.PlanUpgrade(ctx, clone, me)
+	if err != nil {
+		return Hint{}, err
+	}
+	if upgrade == nil {
+		return Hint{Reason: ReasonEndTurn}, nil
+	}
+	if err := checkUpgrade(g, player, *upgrade); err != nil {
+		return Hint{}, err
+	}
+
+	cell, _ := g.Board.GetCell(upgrade.Cell)
+	reason := ReasonRaisesLevel
+	for _, neighbor := range cell.GetNeighbors(g.Board) {
+		if neighbor.Owner() == player {
+			reason = ReasonBoostsNeighbors
+			break
+		}
+	}
+	return Hint{Upgrade: upgrade, Reason: reason}, nil
+}
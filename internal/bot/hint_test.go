@@ -0,0 +1,66 @@
+package bot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggest(t *testing.T) {
+	g, err := game.TestGameAttack()
+	require.NoError(t, err)
+	player := g.Players[0]
+	greedy, err := bot.New("greedy")
+	require.NoError(t, err)
+
+	before := g.ToMap()
+	hint, err := bot.Suggest(context.Background(), g, player, greedy)
+	require.NoError(t, err)
+	assert.Equal(t, before, g.ToMap())
+
+	require.NotNil(t, hint.Attack)
+	assert.Nil(t, hint.Upgrade)
+	assert.Contains(t, []string{bot.ReasonCaptures, bot.ReasonWeakens}, hint.Reason)
+
+	// In the upgrade phase an upgrade is suggested
+	require.NoError(t, g.EndAttack(player))
+	hint, err = bot.Suggest(context.Background(), g, player, greedy)
+	require.NoError(t, err)
+	require.NotNil(t, hint.Upgrade)
+	assert.Nil(t, hint.Attack)
+	assert.Contains(t, []string{bot.ReasonBoostsNeighbors, bot.ReasonRaisesLevel}, hint.Reason)
+
+	// The other player cannot get hints out of turn
+	_, err = bot.Suggest(context.Background(), g, g.Players[1], greedy)
+	assert.Error(t, err)
+}
+
+// passiveStrategy never makes moves.
+type passiveStrategy struct{}
+
+func (passiveStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*bot.Attack, error) {
+	return nil, nil
+}
+
+func (passiveStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*bot.Upgrade, error) {
+	return nil, nil
+}
+
+func TestSuggest_endPhase(t *testing.T) {
+	g, err := game.NewGame(5, 5, 2, 0)
+	require.NoError(t, err)
+	player := g.Players[0]
+
+	hint, err := bot.Suggest(context.Background(), g, player, passiveStrategy{})
+	require.NoError(t, err)
+	assert.Equal(t, bot.Hint{Reason: bot.ReasonEndAttack}, hint)
+
+	require.NoError(t, g.EndAttack(player))
+	hint, err = bot.Suggest(context.Background(), g, player, passiveStrategy{})
+	require.NoError(t, err)
+	assert.Equal(t, bot.Hint{Reason: bot.ReasonEndTurn}, hint)
+}
@@ -0,0 +1,309 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+// mctsOptions configure the registered "mcts" bot.
+var mctsOptions []MCTSOption
+
+func init() {
+	Register(Info{
+		Name:        "mcts",
+		Description: "Searches its moves with Monte Carlo Tree Search over simulated turns",
+		Level:       3,
+	}, func() Strategy { return NewMCTS(mctsOptions...) })
+}
+
+// ConfigureMCTS sets the options of the registered "mcts" bot, replacing the previous ones.
+// It must be called before the bots are created.
+func ConfigureMCTS(options ...MCTSOption) {
+	mctsOptions = options
+}
+
+// mctsStrategy chooses every move with Monte Carlo Tree Search.
+// The tree is built from the moves of the bot within the current phase,
+// then the game is played out by the playout bot for a few more turns.
+type mctsStrategy struct {
+	iterations   int           // Max count of search iterations per move
+	budget       time.Duration // Max time of search per move
+	playoutTurns int           // Count of turns played after the current one
+	playout      string        // Name of the bot that makes moves in playouts
+	exploration  float64       // Exploration constant of UCB1
+	rand         *rand.Rand
+	seeded       bool // The search ignores the time budget, so that its moves are reproducible
+}
+
+// MCTSOption configures the Monte Carlo Tree Search bot.
+type MCTSOption func(*mctsStrategy)
+
+// NewMCTS creates a Monte Carlo Tree Search bot.
+// The search of every move stops when either the iterations or the time budget run out.
+// A seeded bot searches for all the iterations, unless the context is done,
+// so that the moves of a seeded game do not depend on the speed of the machine.
+func NewMCTS(options ...MCTSOption) Strategy {
+	s := &mctsStrategy{
+		iterations:   100,
+		budget:       50 * time.Millisecond,
+		playoutTurns: 4,
+		playout:      "greedy",
+		exploration:  math.Sqrt2,
+		rand:         newRand(),
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// WithIterations sets the max count of search iterations per move.
+func WithIterations(iterations int) MCTSOption {
+	return func(s *mctsStrategy) {
+		s.iterations = iterations
+	}
+}
+
+// WithTimeBudget sets the max time of search per move.
+// Seeded bots ignore it, e.g. the bots of the games served by the apiserver.
+func WithTimeBudget(budget time.Duration) MCTSOption {
+	return func(s *mctsStrategy) {
+		s.budget = budget
+	}
+}
+
+// WithPlayoutTurns sets the count of turns played after the current one.
+func WithPlayoutTurns(turns int) MCTSOption {
+	return func(s *mctsStrategy) {
+		s.playoutTurns = turns
+	}
+}
+
+// WithPlayout sets the name of the bot that makes moves in playouts, e.g. "random" or "greedy".
+func WithPlayout(name string) MCTSOption {
+	return func(s *mctsStrategy) {
+		s.playout = name
+	}
+}
+
+// mctsMove is a move in the search tree.
+// Both fields are nil for the move that ends the current phase.
+type mctsMove struct {
+	attack  *Attack
+	upgrade *Upgrade
+}
+
+// mctsNode is a node of the search tree.
+type mctsNode struct {
+	move     mctsMove
+	parent   *mctsNode
+	children []*mctsNode
+	untried  []mctsMove
+	visits   int
+	reward   float64 // Sum of the rewards of all playouts through the node
+	terminal bool    // The phase has ended, the node cannot have children
+}
+
+// PlanAttack searches the best attack, or nil if the attack phase should end.
+func (s *mctsStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	if len(legalAttacks(g, player)) == 0 {
+		return nil, nil
+	}
+
+	move, err := s.search(ctx, g, player, true)
+	if err != nil {
+		return nil, err
+	}
+	return move.attack, nil
+}
+
+// PlanUpgrade searches the best upgrade, or nil if the turn should end.
+func (s *mctsStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	if len(legalUpgrades(g, player)) == 0 {
+		return nil, nil
+	}
+
+	move, err := s.search(ctx, g, player, false)
+	if err != nil {
+		return nil, err
+	}
+	return move.upgrade, nil
+}
+
+// search runs the tree search and returns the most visited move of the root.
+// The search stops early when the context is done.
+func (s *mctsStrategy) search(ctx context.Context, g *game.Game, player game.Player, attacking bool) (mctsMove, error) {
+	root := &mctsNode{untried: s.legalMoves(g, player, attacking)}
+	deadline := time.Now().Add(s.budget)
+
+	for i := 0; i < s.iterations && (s.seeded || time.Now().Before(deadline)) && ctx.Err() == nil; i++ {
+		sim := g.Clone()
+		simPlayer := sim.Players[player.Id()]
+
+		// Selection
+		node := root
+		for len(node.untried) == 0 && len(node.children) > 0 {
+			node = s.selectChild(node)
+			s.applyMove(sim, simPlayer, node.move, attacking)
+		}
+
+		// Expansion
+		if len(node.untried) > 0 && !node.terminal {
+			idx := s.rand.Intn(len(node.untried))
+			move := node.untried[idx]
+			node.untried = append(node.untried[:idx], node.untried[idx+1:]...)
+
+			ended := s.applyMove(sim, simPlayer, move, attacking)
+			child := &mctsNode{move: move, parent: node, terminal: ended}
+			if !ended {
+				child.untried = s.legalMoves(sim, simPlayer, attacking)
+			}
+			node.children = append(node.children, child)
+			node = child
+		}
+
+		// Simulation
+		reward, err := s.simulate(sim, simPlayer)
+		if err != nil {
+			return mctsMove{}, err
+		}
+
+		// Backpropagation
+		for ; node != nil; node = node.parent {
+			node.visits++
+			node.reward += reward
+		}
+	}
+
+	var best *mctsNode
+	for _, child := range root.children {
+		consider(ctx, Candidate{Attack: child.move.attack, Upgrade: child.move.upgrade, Score: child.reward / float64(child.visits)})
+		if best == nil || child.visits > best.visits {
+			best = child
+		}
+	}
+	if best == nil {
+		return mctsMove{}, nil
+	}
+	return best.move, nil
+}
+
+// legalMoves returns the moves of the player in the phase, including the end of the phase.
+func (s *mctsStrategy) legalMoves(g *game.Game, player game.Player, attacking bool) []mctsMove {
+	moves := []mctsMove{{}}
+	if g.IsFinished() || g.Turn() != player.Id() {
+		return moves
+	}
+
+	if attacking {
+		for _, attack := range legalAttacks(g, player) {
+			attack := attack
+			moves = append(moves, mctsMove{attack: &attack})
+		}
+	} else {
+		for _, upgrade := range legalUpgrades(g, player) {
+			upgrade := upgrade
+			moves = append(moves, mctsMove{upgrade: &upgrade})
+		}
+	}
+	return moves
+}
+
+// applyMove makes the move in the simulated game.
+// It returns true if the move has ended the phase.
+func (s *mctsStrategy) applyMove(g *game.Game, player game.Player, move mctsMove, attacking bool) bool {
+	switch {
+	case move.attack != nil:
+		if applyAttack(g, player, move.attack) != nil {
+			g.EndAttack(player)
+			return true
+		}
+	case move.upgrade != nil:
+		if applyUpgrade(g, player, move.upgrade) != nil {
+			g.EndTurn(player)
+			return true
+		}
+	case attacking:
+		g.EndAttack(player)
+		return true
+	default:
+		g.EndTurn(player)
+		return true
+	}
+	return g.IsFinished()
+}
+
+// selectChild returns the child with the best UCB1 score.
+func (s *mctsStrategy) selectChild(node *mctsNode) *mctsNode {
+	var best *mctsNode
+	bestScore := math.Inf(-1)
+	for _, child := range node.children {
+		score := child.reward/float64(child.visits) +
+			s.exploration*math.Sqrt(math.Log(float64(node.visits))/float64(child.visits))
+		if score > bestScore {
+			bestScore = score
+			best = child
+		}
+	}
+	return best
+}
+
+// simulate finishes the turn of the player, plays the next turns
+// and returns the reward of the player from 0 to 1.
+func (s *mctsStrategy) simulate(g *game.Game, player game.Player) (float64, error) {
+	turns := 0
+	if g.Turn() == player.Id() {
+		// The player's turn is not finished yet
+		turns = -1
+	}
+
+	for ; turns < s.playoutTurns && !g.IsFinished(); turns++ {
+		current := g.Players[g.Turn()]
+		playout, err := New(s.playout)
+		if err != nil {
+			return 0, err
+		}
+		seedStrategy(playout, childRand(s.rand))
+
+		DoAttack(context.Background(), g, current, playout, 0)
+		DoUpgrade(context.Background(), g, current, playout, 0)
+	}
+
+	return evaluate(g, player), nil
+}
+
+// evaluate returns the reward of the player from 0 to 1:
+// the result of the finished game or the share of the owned cells.
+func evaluate(g *game.Game, player game.Player) float64 {
+	if g.IsDraw() {
+		return 0.5
+	}
+	if winner := g.Winner(); winner != nil {
+		if winner.Id() == player.Id() {
+			return 1
+		}
+		return 0
+	}
+
+	total := 0
+	for _, p := range g.Players {
+		total += p.CellsCount()
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(player.CellsCount()) / float64(total)
+}
+
+// setRand replaces the source of the random choices of the search and the playouts
+// and makes the search ignore the time budget.
+func (s *mctsStrategy) setRand(r *rand.Rand) {
+	s.rand = r
+	s.seeded = true
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+// legalAttacks returns all attacks the player can make.
+func legalAttacks(g *game.Game, player game.Player) []Attack {
+	var attacks []Attack
+	for _, cell := range ownedCells(g, player) {
+		if cell.Power() <= 1 {
+			continue
+		}
+
+		for _, neighbor := range cell.GetNeighbors(g.Board) {
+			if neighbor.Owner() != player {
+				attacks = append(attacks, Attack{From: cell.Coords(), To: neighbor.Coords()})
+			}
+		}
+	}
+	return attacks
+}
+
+// legalUpgrades returns all upgrades by one level the player can afford.
+func legalUpgrades(g *game.Game, player game.Player) []Upgrade {
+	var upgrades []Upgrade
+	for _, cell := range ownedCells(g, player) {
+		if upgradeCost(cell) <= player.Points() {
+			upgrades = append(upgrades, Upgrade{Cell: cell.Coords(), Levels: 1})
+		}
+	}
+	return upgrades
+}
+
+// checkAttack returns an error if the player cannot make the attack.
+func checkAttack(g *game.Game, player game.Player, attack Attack) error {
+	for _, legal := range legalAttacks(g, player) {
+		if legal == attack {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: attack from %v to %v", errIllegalMove, attack.From, attack.To)
+}
+
+// checkUpgrade returns an error if the player cannot make the upgrade.
+func checkUpgrade(g *game.Game, player game.Player, upgrade Upgrade) error {
+	cell, err := g.Board.GetCell(upgrade.Cell)
+	if err != nil || cell == nil || cell.Owner() != player || upgrade.Levels < 1 ||
+		upgradeCostLevels(cell, upgrade.Levels) > player.Points() {
+		return fmt.Errorf("%w: upgrade of %v by %d levels", errIllegalMove, upgrade.Cell, upgrade.Levels)
+	}
+	return nil
+}
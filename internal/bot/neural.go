@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/Vacym/neighbors-force/internal/neural"
+)
+
+// neuralStrategy makes the move after which the value network expects the best result.
+type neuralStrategy struct {
+	network *neural.Network
+}
+
+// NewNeural creates the bot that scores the positions after its moves by the value network.
+// On boards of another size than the network was trained for it plays as the greedy bot.
+func NewNeural(network *neural.Network) Strategy {
+	return neuralStrategy{network: network}
+}
+
+// RegisterNeural makes the bot with the value network available by the name.
+// It panics if the name is already taken.
+func RegisterNeural(name string, network *neural.Network) {
+	Register(Info{
+		Name:        name,
+		Description: "Makes the move after which its value network expects the best result",
+		Level:       -1,
+	}, func() Strategy { return NewNeural(network) })
+}
+
+// fits reports whether the network evaluates the positions of the game.
+func (s neuralStrategy) fits(g *game.Game) bool {
+	return g.Board.Rows() == s.network.Rows && g.Board.Cols() == s.network.Cols
+}
+
+// evaluate returns the estimated probability of the win of the player in the position.
+func (s neuralStrategy) evaluate(g *game.Game, player game.Player) float64 {
+	return s.network.Evaluate(neural.Features(neural.PositionOf(g), player.Id()))
+}
+
+// PlanAttack returns the attack with the best value, or nil if ending the attack is better.
+func (s neuralStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	if !s.fits(g) {
+		return greedyStrategy{}.PlanAttack(ctx, g, player)
+	}
+
+	var best *Attack
+	bestValue := s.evaluate(g, player)
+	consider(ctx, Candidate{Score: bestValue})
+	for _, attack := range legalAttacks(g, player) {
+		if err := ctx.Err(); err != nil {
+			break
+		}
+
+		result := g.Clone()
+		if applyAttack(result, result.Players[player.Id()], &attack) != nil {
+			continue
+		}
+
+		value := s.evaluate(result, result.Players[player.Id()])
+		considerAttack(ctx, attack, value)
+		if value > bestValue {
+			attack := attack
+			best = &attack
+			bestValue = value
+		}
+	}
+	return best, nil
+}
+
+// PlanUpgrade returns the upgrade with the best value, or nil if ending the turn is better.
+func (s neuralStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	if !s.fits(g) {
+		return greedyStrategy{}.PlanUpgrade(ctx, g, player)
+	}
+
+	var best *Upgrade
+	bestValue := s.evaluate(g, player)
+	consider(ctx, Candidate{Score: bestValue})
+	for _, upgrade := range legalUpgrades(g, player) {
+		if err := ctx.Err(); err != nil {
+			break
+		}
+
+		result := g.Clone()
+		if applyUpgrade(result, result.Players[player.Id()], &upgrade) != nil {
+			continue
+		}
+
+		value := s.evaluate(result, result.Players[player.Id()])
+		considerUpgrade(ctx, upgrade, value)
+		if value > bestValue {
+			upgrade := upgrade
+			best = &upgrade
+			bestValue = value
+		}
+	}
+	return best, nil
+}
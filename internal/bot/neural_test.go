@@ -0,0 +1,41 @@
+package bot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/Vacym/neighbors-force/internal/neural"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeural(t *testing.T) {
+	g, err := game.NewGame(5, 5, 2, 1)
+	require.NoError(t, err)
+	strategy := bot.NewNeural(neural.NewNetwork(5, 5, []int{8}, 1))
+
+	// Even an untrained network makes only legal moves until the game ends
+	for round := 0; round < 200 && !g.IsFinished(); round++ {
+		player := g.Players[g.Turn()]
+		require.NoError(t, bot.PlayTurn(context.Background(), g, player, strategy, bot.Budget{}))
+	}
+}
+
+func TestNeural_otherBoard(t *testing.T) {
+	g, err := game.TestGameAttack()
+	require.NoError(t, err)
+	player := g.Players[0]
+
+	// On a board the network was not trained for the bot plays as the greedy one
+	greedy, err := bot.New("greedy")
+	require.NoError(t, err)
+	want, err := greedy.PlanAttack(context.Background(), g, player)
+	require.NoError(t, err)
+
+	strategy := bot.NewNeural(neural.NewNetwork(g.Board.Rows()+2, g.Board.Cols(), []int{8}, 1))
+	attack, err := strategy.PlanAttack(context.Background(), g, player)
+	require.NoError(t, err)
+	assert.Equal(t, want, attack)
+}
@@ -0,0 +1,200 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+var errIncorrectProfile = errors.New("incorrect bot profile")
+
+//go:embed profiles.toml
+var builtinProfiles []byte
+
+func init() {
+	for _, profile := range BuiltinProfiles() {
+		RegisterProfile(profile)
+	}
+}
+
+// Profile holds the weights of the heuristic bot. Every weight is between 0 and 1.
+type Profile struct {
+	Name        string  `toml:"-"`
+	Description string  `toml:"description"`
+	Aggression  float64 `toml:"aggression"` // How readily the bot leaves its cells weak to attack
+	Expansion   float64 `toml:"expansion"`  // Preference of free cells (1) over enemy cells (0)
+	Defense     float64 `toml:"defense"`    // Preference of upgrades that support the frontier (1) over the core (0)
+	Risk        float64 `toml:"risk"`       // Willingness to make attacks that only weaken a stronger cell
+}
+
+// validate checks that every weight of the profile is between 0 and 1.
+func (p Profile) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("%w: empty name", errIncorrectProfile)
+	}
+
+	weights := map[string]float64{
+		"aggression": p.Aggression,
+		"expansion":  p.Expansion,
+		"defense":    p.Defense,
+		"risk":       p.Risk,
+	}
+	for name, weight := range weights {
+		if weight < 0 || weight > 1 {
+			return fmt.Errorf("%w: %s of %q is %v, not between 0 and 1", errIncorrectProfile, name, p.Name, weight)
+		}
+	}
+	return nil
+}
+
+// LoadProfiles reads the profiles from TOML, where every table is a profile named by its key.
+// The profiles are sorted by name.
+func LoadProfiles(r io.Reader) ([]Profile, error) {
+	var tables map[string]Profile
+	meta, err := toml.NewDecoder(r).Decode(&tables)
+	if err != nil {
+		return nil, err
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("%w: unknown key %q", errIncorrectProfile, undecoded[0].String())
+	}
+
+	profiles := make([]Profile, 0, len(tables))
+	for name, profile := range tables {
+		profile.Name = name
+		if err := profile.validate(); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// BuiltinProfiles returns the built-in profiles sorted by name.
+func BuiltinProfiles() []Profile {
+	profiles, err := LoadProfiles(bytes.NewReader(builtinProfiles))
+	if err != nil {
+		panic(fmt.Sprintf("bot: built-in profiles: %v", err))
+	}
+	return profiles
+}
+
+// RegisterProfile makes the heuristic bot with the profile available by the name of the profile.
+// It panics if the name is already taken.
+func RegisterProfile(profile Profile) {
+	Register(Info{
+		Name:        profile.Name,
+		Description: profile.Description,
+		Level:       -1,
+	}, func() Strategy { return NewProfile(profile) })
+}
+
+// profileStrategy makes the best move by the weights of its profile.
+type profileStrategy struct {
+	profile Profile
+}
+
+// NewProfile creates the heuristic bot with the weights of the profile.
+func NewProfile(profile Profile) Strategy {
+	return profileStrategy{profile: profile}
+}
+
+// PlanAttack returns the attack with the best score, if it is worth the risk.
+func (s profileStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	var best *Attack
+	bestScore := 0.0
+	for _, attack := range legalAttacks(g, player) {
+		score := s.attackScore(g, player, attack)
+		considerAttack(ctx, attack, score)
+		if score > bestScore {
+			bestScore = score
+			best = &Attack{From: attack.From, To: attack.To}
+		}
+	}
+
+	// Cautious bots keep their power instead of making weak attacks
+	if bestScore < (1-s.profile.Aggression)*0.75 {
+		return nil, nil
+	}
+	return best, nil
+}
+
+// attackScore scores the attack by the weights of the profile.
+func (s profileStrategy) attackScore(g *game.Game, player game.Player, attack Attack) float64 {
+	from, _ := g.Board.GetCell(attack.From)
+	to, _ := g.Board.GetCell(attack.To)
+	p := s.profile
+
+	var score float64
+	margin := from.Power() - to.Power()
+	switch {
+	case margin > 0 && to.Owner() == nil:
+		score = 1 + p.Expansion
+	case margin > 0:
+		score = 1 + (1 - p.Expansion) + 0.5*float64(to.Level()-1)
+	case to.Owner() != nil:
+		// The attack only weakens the enemy cell
+		score = p.Risk * 0.5 * float64(from.Power()) / float64(to.Power())
+	}
+
+	// The captured cell keeps the rest of the power, which protects it
+	if margin > 0 {
+		score += 0.1 * (1 - p.Risk) * float64(margin)
+	}
+
+	// The attacking cell is left with power 1, so enemies next to it may capture it
+	for _, neighbor := range from.GetNeighbors(g.Board) {
+		if neighbor.Owner() != nil && neighbor.Owner() != player && neighbor.Power() > 1 && neighbor != to {
+			score -= 0.5 * (1 - p.Aggression)
+			break
+		}
+	}
+	return score
+}
+
+// PlanUpgrade upgrades the affordable cell that supports the most valuable neighbors.
+func (s profileStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	var best *Upgrade
+	bestScore := -1.0
+	for _, upgrade := range legalUpgrades(g, player) {
+		cell, _ := g.Board.GetCell(upgrade.Cell)
+		score := s.upgradeScore(g, player, cell)
+		considerUpgrade(ctx, upgrade, score)
+		if score > bestScore {
+			bestScore = score
+			best = &Upgrade{Cell: upgrade.Cell, Levels: upgrade.Levels}
+		}
+	}
+	return best, nil
+}
+
+// upgradeScore scores the upgrade of the cell by the weights of the profile.
+// An upgraded cell gives power to its neighbors of the same owner.
+func (s profileStrategy) upgradeScore(g *game.Game, player game.Player, cell game.Cell) float64 {
+	var frontier, core int
+	for _, neighbor := range cell.GetNeighbors(g.Board) {
+		if neighbor.Owner() != player {
+			continue
+		}
+		if isFrontier(g, neighbor) {
+			frontier++
+		} else {
+			core++
+		}
+	}
+
+	p := s.profile
+	score := p.Defense*float64(frontier) + (1-p.Defense)*float64(core)
+
+	// Cheaper upgrades leave points for more of them
+	return score - 0.01*float64(upgradeCost(cell))
+}
@@ -0,0 +1,135 @@
+package bot_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	testCases := []struct {
+		name    string
+		toml    string
+		isValid bool
+	}{
+		{
+			name: "valid",
+			toml: `
+				[careful]
+				description = "Careful bot"
+				aggression = 0.1
+				expansion = 1
+				defense = 0.5
+				risk = 0`,
+			isValid: true,
+		},
+		{
+			name:    "weight out of range",
+			toml:    "[careful]\naggression = 1.5",
+			isValid: false,
+		},
+		{
+			name:    "negative weight",
+			toml:    "[careful]\nrisk = -0.1",
+			isValid: false,
+		},
+		{
+			name:    "unknown key",
+			toml:    "[careful]\nagression = 0.5",
+			isValid: false,
+		},
+		{
+			name:    "malformed",
+			toml:    "[careful",
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			profiles, err := bot.LoadProfiles(strings.NewReader(tc.toml))
+			if !tc.isValid {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, profiles, 1)
+			assert.Equal(t, "careful", profiles[0].Name)
+			assert.Equal(t, 0.1, profiles[0].Aggression)
+		})
+	}
+}
+
+func TestProfile_builtin(t *testing.T) {
+	names := make(map[string]bool)
+	for _, info := range bot.List() {
+		names[info.Name] = true
+	}
+	for _, name := range []string{"balanced", "turtle", "rusher"} {
+		assert.True(t, names[name], name)
+	}
+
+	g, err := game.NewGame(7, 7, 3, 1)
+	require.NoError(t, err)
+
+	strategies := make([]bot.Strategy, len(g.Players))
+	for i, name := range []string{"balanced", "turtle", "rusher"} {
+		strategies[i], err = bot.New(name)
+		require.NoError(t, err)
+	}
+	playGame(t, g, strategies)
+}
+
+func TestProfile_expansion(t *testing.T) {
+	// Player 0 can capture both free and enemy cells
+	g, err := game.TestGameAttack()
+	require.NoError(t, err)
+	player := g.Players[0]
+
+	fighter := bot.NewProfile(bot.Profile{Name: "fighter", Aggression: 1, Expansion: 0})
+	attack, err := fighter.PlanAttack(context.Background(), g, player)
+	require.NoError(t, err)
+	require.NotNil(t, attack)
+
+	to, err := g.Board.GetCell(attack.To)
+	require.NoError(t, err)
+	assert.NotNil(t, to.Owner())
+	assert.NotEqual(t, player, to.Owner())
+}
+
+// riskCounter counts the attacks that cannot capture the target.
+type riskCounter struct {
+	bot.Strategy
+	risky int
+}
+
+// PlanAttack counts the risky attack planned by the strategy.
+func (c *riskCounter) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*bot.Attack, error) {
+	attack, err := c.Strategy.PlanAttack(ctx, g, player)
+	if attack != nil {
+		from, _ := g.Board.GetCell(attack.From)
+		to, _ := g.Board.GetCell(attack.To)
+		if from.Power() <= to.Power() {
+			c.risky++
+		}
+	}
+	return attack, err
+}
+
+func TestProfile_risk(t *testing.T) {
+	g, err := game.NewGame(7, 7, 2, 1)
+	require.NoError(t, err)
+
+	careful := &riskCounter{Strategy: bot.NewProfile(bot.Profile{Name: "careful", Aggression: 1, Risk: 0})}
+	reckless := &riskCounter{Strategy: bot.NewProfile(bot.Profile{Name: "reckless", Aggression: 1, Risk: 1})}
+	playGame(t, g, []bot.Strategy{careful, reckless})
+
+	assert.Zero(t, careful.risky)
+	assert.NotZero(t, reckless.risky)
+}
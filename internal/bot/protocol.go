@@ -0,0 +1,173 @@
+package bot
+
+import "github.com/Vacym/neighbors-force/internal/game"
+
+// ProtocolVersion is the version of the external bot protocol implemented by the client.
+// The protocol is described in docs/bot-protocol.md.
+const ProtocolVersion = 1
+
+// CapabilityTurnPlan means the bot server plans whole turns.
+const CapabilityTurnPlan = "turn_plan"
+
+// Phase is the phase of the turn the plan is requested in.
+type Phase string
+
+const (
+	PhaseAttack  Phase = "attack"  // Attacks and upgrades are planned
+	PhaseUpgrade Phase = "upgrade" // Only upgrades are planned
+)
+
+// Hello is the handshake request of the client.
+type Hello struct {
+	Versions []int `json:"versions"` // Protocol versions supported by the client
+}
+
+// HelloResponse is the handshake response of the bot server.
+type HelloResponse struct {
+	Version      int      `json:"version"`      // Protocol version chosen by the server
+	Name         string   `json:"name"`         // Name of the bot
+	Capabilities []string `json:"capabilities"` // Features supported by the server
+}
+
+// Rules describes the rules of the game.
+type Rules struct {
+	TurnsLimit  int    `json:"turns_limit"`  // Count of rounds after which the player with most cells wins
+	UpgradeCost string `json:"upgrade_cost"` // "triangular": level l is upgraded by one for l*(l+1)/2 points
+	Layout      string `json:"layout"`       // "odd-r": odd rows are shifted by half a cell to the right
+}
+
+// StateCell is a cell of the board.
+type StateCell struct {
+	Row   int  `json:"row"`
+	Col   int  `json:"col"`
+	Power int  `json:"power"`
+	Level int  `json:"level"`
+	Owner *int `json:"owner"` // ID of the owner, null if the cell is free
+}
+
+// StateBoard is the board of the game. Missing cells are null.
+type StateBoard struct {
+	Rows  int            `json:"rows"`
+	Cols  int            `json:"cols"`
+	Cells [][]*StateCell `json:"cells"`
+}
+
+// StatePlayer is a player of the game.
+type StatePlayer struct {
+	Id         int     `json:"id"`
+	Name       string  `json:"name"`
+	Points     int     `json:"points"`
+	CellsCount int     `json:"cells_count"`
+	Resigned   bool    `json:"resigned"`
+	Income     float64 `json:"income"` // Multiplier of the points earned per cell
+}
+
+// State is the full game state sent with a plan request.
+type State struct {
+	Version int           `json:"version"`
+	Player  int           `json:"player"` // ID of the player the plan is requested for
+	Phase   Phase         `json:"phase"`
+	Round   int           `json:"round"` // Count of rounds played
+	Rules   Rules         `json:"rules"`
+	Board   StateBoard    `json:"board"`
+	Players []StatePlayer `json:"players"`
+}
+
+// Plan is a whole turn planned by the bot server.
+// Attacks are made in order, then the attack phase ends and upgrades are made in order.
+type Plan struct {
+	Attacks  []Attack  `json:"attacks"`
+	Upgrades []Upgrade `json:"upgrades"`
+}
+
+// Apply makes the moves of the plan for the player, checking every move before it is made.
+// In the attack phase the attacks are made, then the attack phase ends; the upgrades are made in both phases.
+// The turn is not ended. It returns the error of the first illegal move, the moves after the end of the game are ignored.
+func (p Plan) Apply(g *game.Game, player game.Player, phase Phase) error {
+	if phase == PhaseAttack {
+		for i := range p.Attacks {
+			if g.IsFinished() {
+				return nil
+			}
+			if err := checkAttack(g, player, p.Attacks[i]); err != nil {
+				return err
+			}
+			if err := applyAttack(g, player, &p.Attacks[i]); err != nil {
+				return err
+			}
+		}
+		if g.IsFinished() {
+			return nil
+		}
+		if err := g.EndAttack(player); err != nil {
+			return err
+		}
+	}
+
+	for i := range p.Upgrades {
+		if g.IsFinished() {
+			return nil
+		}
+		if err := checkUpgrade(g, player, p.Upgrades[i]); err != nil {
+			return err
+		}
+		if err := applyUpgrade(g, player, &p.Upgrades[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewState describes the game for the bot server.
+func NewState(g *game.Game, player game.Player, phase Phase) State {
+	state := State{
+		Version: ProtocolVersion,
+		Player:  player.Id(),
+		Phase:   phase,
+		Round:   g.TurnsCount(),
+		Rules: Rules{
+			TurnsLimit:  g.TurnsLimit(),
+			UpgradeCost: "triangular",
+			Layout:      "odd-r",
+		},
+		Board: StateBoard{
+			Rows:  g.Board.Rows(),
+			Cols:  g.Board.Cols(),
+			Cells: make([][]*StateCell, len(g.Board.Cells)),
+		},
+		Players: make([]StatePlayer, len(g.Players)),
+	}
+
+	for i, row := range g.Board.Cells {
+		state.Board.Cells[i] = make([]*StateCell, len(row))
+		for j, cell := range row {
+			if cell == nil {
+				continue
+			}
+
+			stateCell := &StateCell{Row: i, Col: j, Power: cell.Power(), Level: cell.Level()}
+			if cell.Owner() != nil {
+				owner := cell.Owner().Id()
+				stateCell.Owner = &owner
+			}
+			state.Board.Cells[i][j] = stateCell
+		}
+	}
+
+	for i, p := range g.Players {
+		income := p.Handicap().Income
+		if income == 0 {
+			income = 1
+		}
+
+		state.Players[i] = StatePlayer{
+			Id:         p.Id(),
+			Name:       p.Name(),
+			Points:     p.Points(),
+			CellsCount: p.CellsCount(),
+			Resigned:   p.Resigned(),
+			Income:     income,
+		}
+	}
+	return state
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package bot_test
+
+// raceEnabled reports whether the tests are built with the race detector.
+const raceEnabled = true
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+func init() {
+	Register(Info{
+		Name:        "random",
+		Description: "Attacks random neighbors following captured cells and upgrades random cells",
+		Level:       0,
+	}, func() Strategy { return &randomStrategy{rand: newRand()} })
+}
+
+// randomStrategy makes random moves.
+type randomStrategy struct {
+	chain *game.Coords // Cell captured by the last attack, attacks continue from it
+	rand  *rand.Rand
+}
+
+// PlanAttack attacks a random neighbor, preferring to continue from the last captured cell.
+func (s *randomStrategy) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*Attack, error) {
+	if s.chain != nil {
+		from, _ := g.Board.GetCell(*s.chain)
+		s.chain = nil
+		if attack := s.attackFrom(g, player, from); attack != nil {
+			return attack, nil
+		}
+	}
+
+	for _, cell := range ownedCells(g, player) {
+		if attack := s.attackFrom(g, player, cell); attack != nil {
+			return attack, nil
+		}
+	}
+	return nil, nil
+}
+
+// attackFrom plans an attack from the cell to its random alien neighbor.
+func (s *randomStrategy) attackFrom(g *game.Game, player game.Player, from game.Cell) *Attack {
+	if from == nil || from.Owner() != player || from.Power() <= 1 {
+		return nil
+	}
+
+	neighbors := from.GetNeighbors(g.Board)
+	alienNeighbors := filter(neighbors, func(neighbor game.Cell) bool { return neighbor.Owner() != player })
+	if len(alienNeighbors) == 0 {
+		return nil
+	}
+
+	to := alienNeighbors[s.rand.Intn(len(alienNeighbors))]
+
+	// The target is captured if it is weaker than the attacker
+	if to.Power() < from.Power() {
+		coords := to.Coords()
+		s.chain = &coords
+	}
+
+	return &Attack{From: from.Coords(), To: to.Coords()}
+}
+
+// PlanUpgrade upgrades a random cell the player can afford.
+func (s *randomStrategy) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*Upgrade, error) {
+	affordable := filter(ownedCells(g, player), func(cell game.Cell) bool {
+		return upgradeCost(cell) <= player.Points()
+	})
+	if len(affordable) == 0 {
+		return nil, nil
+	}
+
+	cell := affordable[s.rand.Intn(len(affordable))]
+	return &Upgrade{Cell: cell.Coords(), Levels: 1}, nil
+}
+
+// setRand replaces the source of the random choices.
+func (s *randomStrategy) setRand(r *rand.Rand) {
+	s.rand = r
+}
@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Info describes a registered bot.
+type Info struct {
+	Name        string `json:"name"`        // Unique name of the bot
+	Description string `json:"description"` // Short human-readable description
+	Level       int    `json:"level"`       // Difficulty level the bot can be selected by, -1 if none
+}
+
+// registration holds a registered bot.
+type registration struct {
+	info    Info
+	factory func() Strategy
+}
+
+var (
+	registry = make(map[string]registration)
+	levels   = make(map[int]string)
+)
+
+// Register makes a bot available by its name and, if the level is not negative, by its level.
+// It panics if the name or the level is already taken.
+func Register(info Info, factory func() Strategy) {
+	if _, ok := registry[info.Name]; ok {
+		panic(fmt.Sprintf("bot: Register called twice for bot %q", info.Name))
+	}
+	if info.Level >= 0 {
+		if name, ok := levels[info.Level]; ok {
+			panic(fmt.Sprintf("bot: level %d is already taken by bot %q", info.Level, name))
+		}
+		levels[info.Level] = info.Name
+	}
+
+	registry[info.Name] = registration{
+		info:    info,
+		factory: factory,
+	}
+}
+
+// New creates the strategy of the bot with the given name.
+func New(name string) (Strategy, error) {
+	reg, ok := registry[name]
+	if !ok {
+		return nil, errUnknownBot
+	}
+	return reg.factory(), nil
+}
+
+// Lookup returns the description of the bot with the given name.
+func Lookup(name string) (Info, error) {
+	reg, ok := registry[name]
+	if !ok {
+		return Info{}, errUnknownBot
+	}
+	return reg.info, nil
+}
+
+// LookupLevel returns the description of the bot with the given difficulty level.
+func LookupLevel(level int) (Info, error) {
+	name, ok := levels[level]
+	if !ok {
+		return Info{}, errIncorrectDifficulty
+	}
+	return Lookup(name)
+}
+
+// NewByLevel creates the strategy of the bot with the given difficulty level.
+func NewByLevel(level int) (Strategy, error) {
+	name, ok := levels[level]
+	if !ok {
+		return nil, errIncorrectDifficulty
+	}
+	return New(name)
+}
+
+// List returns the descriptions of all registered bots ordered by level, then by name.
+func List() []Info {
+	result := make([]Info, 0, len(registry))
+	for _, reg := range registry {
+		result = append(result, reg.info)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		levelI, levelJ := result[i].Level, result[j].Level
+		if levelI != levelJ {
+			if levelI < 0 || levelJ < 0 {
+				return levelJ < 0
+			}
+			return levelI < levelJ
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"errors"
+	"time"
+)
+
+var errSandboxUnsupported = errors.New("sandbox is not supported on this platform")
+
+// SandboxLimits limits the resources of an untrusted engine. Zero values mean the defaults.
+type SandboxLimits struct {
+	CPUTime   time.Duration // CPU time of the process over its whole life
+	Memory    int64         // Bytes of the address space
+	FileSize  int64         // Bytes of a file written by the process
+	OpenFiles int           // Count of open file descriptors
+	Output    int64         // Bytes written to stdout over the whole life
+}
+
+// DefaultSandboxLimits are the limits used for the zero fields of SandboxLimits.
+var DefaultSandboxLimits = SandboxLimits{
+	CPUTime:   10 * time.Minute,
+	Memory:    1 << 30,
+	FileSize:  10 << 20,
+	OpenFiles: 64,
+	Output:    64 << 20,
+}
+
+// withDefaults returns the limits with the zero fields set to the defaults.
+func (l SandboxLimits) withDefaults() SandboxLimits {
+	if l.CPUTime <= 0 {
+		l.CPUTime = DefaultSandboxLimits.CPUTime
+	}
+	if l.Memory <= 0 {
+		l.Memory = DefaultSandboxLimits.Memory
+	}
+	if l.FileSize <= 0 {
+		l.FileSize = DefaultSandboxLimits.FileSize
+	}
+	if l.OpenFiles <= 0 {
+		l.OpenFiles = DefaultSandboxLimits.OpenFiles
+	}
+	if l.Output <= 0 {
+		l.Output = DefaultSandboxLimits.Output
+	}
+	return l
+}
+
+// WithSandbox runs the engine isolated within the limits.
+// The engine gets an empty environment and a private working directory that is removed when it exits.
+// A relative path of the engine is resolved against the working directory of the server,
+// but its arguments should not be relative to it.
+func WithSandbox(limits SandboxLimits) EngineOption {
+	return func(s *engineStrategy) {
+		s.launcher = sandboxLauncher{limits: limits.withDefaults()}
+	}
+}
@@ -0,0 +1,71 @@
+//go:build linux
+
+package bot
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// sandboxScript sets the limits of the shell, which are inherited by the engine it is replaced with,
+// so the limits are set before the engine runs any code.
+// The engine is started by env, because shells add their own variables to the environment.
+const sandboxScript = `ulimit -t %d && ulimit -v %d && ulimit -f %d && ulimit -n %d && exec /usr/bin/env -i "$0" "$@"`
+
+// sandboxLauncher runs the engine with rlimits in a private working directory.
+type sandboxLauncher struct {
+	limits SandboxLimits
+}
+
+// command prepares the process of the engine.
+func (l sandboxLauncher) command(path string, args []string) (*exec.Cmd, error) {
+	path, err := exec.LookPath(path)
+	if err != nil {
+		return nil, err
+	}
+	// The engine runs in another directory, so a relative path would not be found
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "neighbors-force-engine-")
+	if err != nil {
+		return nil, err
+	}
+
+	script := fmt.Sprintf(sandboxScript,
+		max(1, int64(math.Ceil(l.limits.CPUTime.Seconds()))),
+		l.limits.Memory>>10,
+		// Blocks are 512 bytes in some shells and 1024 in others, so the limit is never exceeded
+		l.limits.FileSize>>10,
+		l.limits.OpenFiles,
+	)
+
+	cmd := exec.Command("/bin/sh", append([]string{"-c", script, path}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = []string{}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid:   true,            // Children of the engine are killed with it
+		Pdeathsig: syscall.SIGKILL, // The engine does not outlive the server
+	}
+	return cmd, nil
+}
+
+// kill kills the engine with all its children.
+func (sandboxLauncher) kill(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// cleanup removes the working directory of the engine.
+func (sandboxLauncher) cleanup(cmd *exec.Cmd) {
+	os.RemoveAll(cmd.Dir)
+}
+
+// outputLimit returns the max count of bytes the engine may write to stdout.
+func (l sandboxLauncher) outputLimit() int64 {
+	return l.limits.Output
+}
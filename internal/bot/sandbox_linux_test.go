@@ -0,0 +1,145 @@
+//go:build linux
+
+package bot_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// skipUnderRace skips the test with the race detector, which cannot start within the address space limit.
+func skipUnderRace(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector needs more address space than the sandbox allows")
+	}
+}
+
+// runSandboxedTestEngine answers a move in the modes that check the sandbox.
+// It answers "bestmove none" if the sandbox works, anything else otherwise.
+func runSandboxedTestEngine(mode string) {
+	switch mode {
+	case "isolated":
+		dir, _ := os.Getwd()
+		entries, _ := os.ReadDir(dir)
+		if len(os.Environ()) != 0 || len(entries) != 0 ||
+			!strings.HasPrefix(filepath.Base(dir), "neighbors-force-engine-") {
+			fmt.Println("bestmove escaped")
+			return
+		}
+		os.WriteFile("state", []byte("state"), 0o600)
+		fmt.Println("bestmove none")
+	case "spam":
+		for {
+			fmt.Println("info " + strings.Repeat("spam", 1000))
+		}
+	case "memory":
+		memory := make([]byte, 2<<30)
+		for i := range memory {
+			memory[i] = 1
+		}
+		fmt.Println("bestmove none")
+	case "spin":
+		for {
+		}
+	case "bigfile":
+		err := os.WriteFile("big", make([]byte, 1<<20), 0o600)
+		if err == nil {
+			fmt.Println("bestmove escaped")
+			return
+		}
+		fmt.Println("bestmove none")
+	}
+}
+
+func TestSandbox_isolated(t *testing.T) {
+	skipUnderRace(t)
+	t.Setenv("NEIGHBORS_FORCE_SECRET", "secret")
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	strategy := newTestEngine(t, "isolated", bot.WithEngineFallback(""), bot.WithSandbox(bot.SandboxLimits{}))
+	attack, err := strategy.PlanAttack(context.Background(), g, g.Players[0])
+	require.NoError(t, err)
+	assert.Nil(t, attack)
+
+	// The working directory is removed with the engine
+	require.NoError(t, strategy.(io.Closer).Close())
+	entries, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSandbox_relativePath(t *testing.T) {
+	skipUnderRace(t)
+
+	executable, err := os.Executable()
+	require.NoError(t, err)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(filepath.Dir(executable)))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	path := "." + string(filepath.Separator) + filepath.Base(executable)
+	strategy := bot.NewEngine(path, []string{engineArg, "attack"}, bot.WithEngineFallback(""), bot.WithSandbox(bot.SandboxLimits{}))
+	t.Cleanup(func() { strategy.(io.Closer).Close() })
+
+	attack, err := strategy.PlanAttack(context.Background(), g, g.Players[0])
+	require.NoError(t, err)
+	assert.NotNil(t, attack)
+}
+
+func TestSandbox_limits(t *testing.T) {
+	skipUnderRace(t)
+
+	testCases := []struct {
+		mode   string
+		limits bot.SandboxLimits
+		broken bool // The limit makes the engine fail rather than answer
+	}{
+		{mode: "spam", limits: bot.SandboxLimits{Output: 1 << 20}, broken: true},
+		{mode: "memory", limits: bot.SandboxLimits{Memory: 1 << 30}, broken: true},
+		{mode: "spin", limits: bot.SandboxLimits{CPUTime: time.Second}, broken: true},
+		{mode: "bigfile", limits: bot.SandboxLimits{FileSize: 64 << 10}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.mode, func(t *testing.T) {
+			g, err := game.NewCompleteBoardGame(5, 5, 2)
+			require.NoError(t, err)
+
+			strategy := newTestEngine(t, tc.mode,
+				bot.WithEngineFallback(""),
+				bot.WithMoveTimeout(10*time.Second),
+				bot.WithSandbox(tc.limits),
+			)
+
+			start := time.Now()
+			attack, err := strategy.PlanAttack(context.Background(), g, g.Players[0])
+			assert.Nil(t, attack)
+			if tc.broken {
+				assert.Error(t, err)
+				assert.Less(t, time.Since(start), 5*time.Second)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
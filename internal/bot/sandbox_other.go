@@ -0,0 +1,28 @@
+//go:build !linux
+
+package bot
+
+import "os/exec"
+
+// sandboxLauncher fails to run the engine, the sandbox is implemented for Linux only.
+type sandboxLauncher struct {
+	limits SandboxLimits
+}
+
+// command fails, the engine is not run without isolation.
+func (sandboxLauncher) command(path string, args []string) (*exec.Cmd, error) {
+	return nil, errSandboxUnsupported
+}
+
+// kill kills the process of the engine.
+func (sandboxLauncher) kill(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// cleanup releases the resources of the process after it has exited.
+func (sandboxLauncher) cleanup(cmd *exec.Cmd) {}
+
+// outputLimit returns the max count of bytes the engine may write to stdout.
+func (l sandboxLauncher) outputLimit() int64 {
+	return l.limits.Output
+}
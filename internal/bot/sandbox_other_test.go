@@ -0,0 +1,6 @@
+//go:build !linux
+
+package bot_test
+
+// runSandboxedTestEngine ignores the modes that check the sandbox, which is implemented for Linux only.
+func runSandboxedTestEngine(mode string) {}
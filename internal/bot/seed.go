@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"time"
+)
+
+// randomized is implemented by the strategies that make random choices.
+type randomized interface {
+	// setRand replaces the source of the random choices.
+	setRand(r *rand.Rand)
+}
+
+// Seed makes the random choices of the strategy reproducible: they are drawn from a source
+// derived from the seed of the game and the seat of the bot. A replayed game with the same seed
+// and the same moves of the humans gets the same moves of the bots.
+// Strategies without random choices are not changed.
+func Seed(strategy Strategy, seed int64, seat int) {
+	seedStrategy(strategy, rand.New(rand.NewSource(seatSeed(seed, seat))))
+}
+
+// seedStrategy gives the source of the random choices to the strategy, if it makes them.
+func seedStrategy(strategy Strategy, r *rand.Rand) {
+	if s, ok := strategy.(randomized); ok {
+		s.setRand(r)
+	}
+}
+
+// childRand returns a source derived from the given one, so that the strategies
+// created by another strategy make reproducible choices as well.
+func childRand(r *rand.Rand) *rand.Rand {
+	return rand.New(rand.NewSource(r.Int63()))
+}
+
+// newRand returns a source seeded by the current time, for the strategies that are not seeded.
+func newRand() *rand.Rand {
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+// seatSeed mixes the seed of the game with the seat, so that the bots of a game make different choices.
+func seatSeed(seed int64, seat int) int64 {
+	h := fnv.New64a()
+	buf := binary.AppendVarint(nil, seed)
+	buf = binary.AppendVarint(buf, int64(seat))
+	h.Write(buf)
+	return int64(h.Sum64())
+}
@@ -0,0 +1,60 @@
+package bot_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replay plays the game with the seed between the seeded bots and returns the final state.
+func replay(t *testing.T, seed int64, newStrategy func() bot.Strategy) map[string]any {
+	g, err := game.NewGame(5, 5, 2, seed)
+	require.NoError(t, err)
+
+	strategies := make([]bot.Strategy, len(g.Players))
+	for i := range strategies {
+		strategies[i] = newStrategy()
+		bot.Seed(strategies[i], g.Seed(), i)
+	}
+
+	playGame(t, g, strategies)
+	return g.ToMap()
+}
+
+func TestSeed(t *testing.T) {
+	testCases := []struct {
+		name        string
+		newStrategy func() bot.Strategy
+	}{
+		{
+			name:        "random",
+			newStrategy: func() bot.Strategy { s, _ := bot.New("random"); return s },
+		},
+		{
+			name: "mcts",
+			newStrategy: func() bot.Strategy {
+				return bot.NewMCTS(bot.WithIterations(5), bot.WithTimeBudget(time.Hour), bot.WithPlayoutTurns(1), bot.WithPlayout("random"))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, replay(t, 7, tc.newStrategy), replay(t, 7, tc.newStrategy))
+		})
+	}
+
+	// The seeded search runs all the iterations, however short the time budget is
+	t.Run("mcts out of time", func(t *testing.T) {
+		newMCTS := func(budget time.Duration) func() bot.Strategy {
+			return func() bot.Strategy {
+				return bot.NewMCTS(bot.WithIterations(5), bot.WithTimeBudget(budget), bot.WithPlayoutTurns(1), bot.WithPlayout("random"))
+			}
+		}
+		assert.Equal(t, replay(t, 7, newMCTS(time.Hour)), replay(t, 7, newMCTS(time.Nanosecond)))
+	})
+}
@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+// Candidate is a move a bot has considered, scored by the bot's own evaluation.
+// Both moves are nil for the end of the phase.
+type Candidate struct {
+	Attack  *Attack  `json:"attack,omitempty"`
+	Upgrade *Upgrade `json:"upgrade,omitempty"`
+	Score   float64  `json:"score"`
+}
+
+// Decision is the trace of a move planned by a bot.
+// Bots that do not score their moves leave the candidates empty.
+type Decision struct {
+	Round      int         `json:"round"`             // Round of the game the move is planned in
+	Player     int         `json:"player"`            // ID of the player the move is planned for
+	Phase      Phase       `json:"phase"`             // Phase of the turn
+	Candidates []Candidate `json:"candidates"`        // Moves the bot has considered
+	Attack     *Attack     `json:"attack,omitempty"`  // Chosen attack, nil if none
+	Upgrade    *Upgrade    `json:"upgrade,omitempty"` // Chosen upgrade, nil if none
+	Error      string      `json:"error,omitempty"`   // Error of the planning, if any
+	ElapsedMs  int64       `json:"elapsed_ms"`        // Time the bot has thought over the move
+}
+
+// Trace records the decisions of the bots of a game. It is safe for concurrent use.
+type Trace struct {
+	mu        sync.Mutex
+	decisions []Decision
+}
+
+// NewTrace creates an empty trace.
+func NewTrace() *Trace {
+	return &Trace{}
+}
+
+// Decisions returns the recorded decisions in the order they were made.
+func (t *Trace) Decisions() []Decision {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	decisions := make([]Decision, len(t.decisions))
+	copy(decisions, t.decisions)
+	return decisions
+}
+
+// add records the decision.
+func (t *Trace) add(decision Decision) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.decisions = append(t.decisions, decision)
+}
+
+type (
+	traceKey    struct{}
+	decisionKey struct{}
+)
+
+// WithTrace returns the context that makes DoAttack and DoUpgrade record the decisions to the trace.
+func WithTrace(ctx context.Context, trace *Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// decisionRecorder collects the decision of a single move.
+type decisionRecorder struct {
+	trace    *Trace
+	decision Decision
+	start    time.Time
+}
+
+// startDecision starts recording the decision of the move if the context has a trace.
+// The returned context passes the recorder to the strategy.
+func startDecision(ctx context.Context, g *game.Game, player game.Player, phase Phase) (context.Context, *decisionRecorder) {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	if trace == nil {
+		return ctx, nil
+	}
+
+	recorder := &decisionRecorder{
+		trace: trace,
+		decision: Decision{
+			Round:      g.TurnsCount(),
+			Player:     player.Id(),
+			Phase:      phase,
+			Candidates: []Candidate{},
+		},
+		start: time.Now(),
+	}
+	return context.WithValue(ctx, decisionKey{}, recorder), recorder
+}
+
+// finish records the chosen move to the trace.
+func (r *decisionRecorder) finish(attack *Attack, upgrade *Upgrade, err error) {
+	if r == nil {
+		return
+	}
+
+	r.decision.Attack = attack
+	r.decision.Upgrade = upgrade
+	if err != nil {
+		r.decision.Error = err.Error()
+	}
+	r.decision.ElapsedMs = time.Since(r.start).Milliseconds()
+	r.trace.add(r.decision)
+}
+
+// consider records the candidate in the decision traced by the context, if any.
+func consider(ctx context.Context, candidate Candidate) {
+	if r, _ := ctx.Value(decisionKey{}).(*decisionRecorder); r != nil {
+		r.decision.Candidates = append(r.decision.Candidates, candidate)
+	}
+}
+
+// considerAttack records the attack with its score in the decision traced by the context, if any.
+func considerAttack(ctx context.Context, attack Attack, score float64) {
+	consider(ctx, Candidate{Attack: &attack, Score: score})
+}
+
+// considerUpgrade records the upgrade with its score in the decision traced by the context, if any.
+func considerUpgrade(ctx context.Context, upgrade Upgrade, score float64) {
+	consider(ctx, Candidate{Upgrade: &upgrade, Score: score})
+}
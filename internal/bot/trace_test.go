@@ -0,0 +1,50 @@
+package bot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	for _, name := range []string{"greedy", "balanced", "chain", "mcts"} {
+		t.Run(name, func(t *testing.T) {
+			g, err := game.TestGameAttack()
+			require.NoError(t, err)
+			player := g.Players[0]
+			strategy, err := bot.New(name)
+			require.NoError(t, err)
+
+			trace := bot.NewTrace()
+			ctx := bot.WithTrace(context.Background(), trace)
+			require.NoError(t, bot.PlayTurn(ctx, g, player, strategy, bot.Budget{}))
+
+			decisions := trace.Decisions()
+			require.NotEmpty(t, decisions)
+
+			first := decisions[0]
+			assert.Equal(t, player.Id(), first.Player)
+			assert.Equal(t, bot.PhaseAttack, first.Phase)
+			require.NotNil(t, first.Attack)
+			assert.NotEmpty(t, first.Candidates)
+
+			// The chosen move is one of the candidates
+			var chosen bool
+			for _, candidate := range first.Candidates {
+				if candidate.Attack != nil && *candidate.Attack == *first.Attack {
+					chosen = true
+				}
+			}
+			assert.True(t, chosen)
+
+			// Every phase ends with a decision without a move
+			last := decisions[len(decisions)-1]
+			assert.Equal(t, bot.PhaseUpgrade, last.Phase)
+			assert.Nil(t, last.Upgrade)
+		})
+	}
+}
@@ -0,0 +1,353 @@
+// Package conformance checks that an external bot server follows the protocol described in docs/bot-protocol.md.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+// maxResponseSize limits the size of a response of the bot server.
+const maxResponseSize = 1 << 20
+
+// Config describes a conformance run.
+type Config struct {
+	Endpoint string        // Base URL of the bot server
+	Timeout  time.Duration // Max time of a single response
+	Legacy   bool          // Check the legacy single move endpoints instead of the versioned protocol
+	Games    int           // Count of full games played against the greedy bot
+	Seed     int64         // Seed of the boards
+}
+
+// NewConfig returns the default config.
+func NewConfig() Config {
+	return Config{
+		Endpoint: bot.DefaultAPIEndpoint,
+		Timeout:  time.Second,
+		Games:    2,
+		Seed:     1,
+	}
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Scenario string        `json:"scenario"`        // Name of the scenario
+	Path     string        `json:"path"`            // Endpoint the scenario was checked at
+	Error    string        `json:"error,omitempty"` // Why the check failed, empty if it passed
+	Elapsed  time.Duration `json:"elapsed"`         // Time of the response, or of the whole game
+}
+
+// Passed reports whether the check passed.
+func (r Result) Passed() bool {
+	return r.Error == ""
+}
+
+// Report holds the results of a conformance run.
+type Report struct {
+	Endpoint string   `json:"endpoint"`
+	Results  []Result `json:"results"`
+}
+
+// Failed returns the count of the failed checks.
+func (r Report) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if !result.Passed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Write writes the report as a human-readable table.
+func (r Report) Write(w io.Writer) error {
+	for _, result := range r.Results {
+		status := "PASS"
+		if !result.Passed() {
+			status = "FAIL"
+		}
+		line := fmt.Sprintf("%s  %-20s %-12s %8s", status, result.Scenario, result.Path, result.Elapsed.Round(time.Millisecond))
+		if !result.Passed() {
+			line += "  " + result.Error
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d of %d checks passed against %s\n", len(r.Results)-r.Failed(), len(r.Results), r.Endpoint)
+	return err
+}
+
+// scenario is a scripted position the bot server is asked to play.
+type scenario struct {
+	name  string
+	phase bot.Phase
+	setup func(seed int64) (*game.Game, error) // Creates the position, the bot plays for the player to move
+}
+
+// scenarios are the positions every bot server is checked in.
+var scenarios = []scenario{
+	{
+		name:  "opening",
+		phase: bot.PhaseAttack,
+		setup: func(seed int64) (*game.Game, error) {
+			return game.NewGame(7, 7, 2, seed)
+		},
+	},
+	{
+		name:  "upgrade phase",
+		phase: bot.PhaseUpgrade,
+		setup: func(seed int64) (*game.Game, error) {
+			g, err := game.NewGame(7, 7, 2, seed)
+			if err != nil {
+				return nil, err
+			}
+			if err := g.SetHandicap(0, game.Handicap{Points: 10, Cells: 3}); err != nil {
+				return nil, err
+			}
+			return g, g.EndAttack(g.Players[0])
+		},
+	},
+	{
+		name:  "handicap",
+		phase: bot.PhaseAttack,
+		setup: func(seed int64) (*game.Game, error) {
+			g, err := game.NewGame(7, 7, 2, seed)
+			if err != nil {
+				return nil, err
+			}
+			return g, g.SetHandicap(0, game.Handicap{Points: 20, Cells: 4, Level: 1, Income: 1.5})
+		},
+	},
+	{
+		name:  "midgame",
+		phase: bot.PhaseAttack,
+		setup: func(seed int64) (*game.Game, error) {
+			return advance(7, 7, 2, seed, 8)
+		},
+	},
+	{
+		name:  "four players",
+		phase: bot.PhaseAttack,
+		setup: func(seed int64) (*game.Game, error) {
+			return advance(9, 9, 4, seed, 2)
+		},
+	},
+}
+
+// advance creates the game and lets the greedy bots play the given count of turns.
+func advance(rows, cols, players int, seed int64, turns int) (*game.Game, error) {
+	g, err := game.NewGame(rows, cols, players, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	greedy, err := bot.New("greedy")
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < turns && !g.IsFinished(); i++ {
+		if err := bot.PlayTurn(context.Background(), g, g.Players[g.Turn()], greedy, bot.Budget{}); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// Run checks the bot server of the config in the scripted scenarios and in full games against the greedy bot.
+func Run(ctx context.Context, config Config) Report {
+	c := &checker{config: config, client: &http.Client{}}
+	report := Report{Endpoint: config.Endpoint}
+
+	if !config.Legacy {
+		report.Results = append(report.Results, c.checkHello(ctx))
+	}
+
+	for _, s := range scenarios {
+		if config.Legacy {
+			if s.phase == bot.PhaseAttack {
+				report.Results = append(report.Results, c.checkLegacy(ctx, s, "/ai_attack"))
+			}
+			report.Results = append(report.Results, c.checkLegacy(ctx, s, "/ai_upgrade"))
+		} else {
+			report.Results = append(report.Results, c.checkPlan(ctx, s))
+		}
+	}
+
+	for i := 0; i < config.Games; i++ {
+		report.Results = append(report.Results, c.checkGame(ctx, i))
+	}
+	return report
+}
+
+// checker sends the requests of the checks to the bot server.
+type checker struct {
+	config Config
+	client *http.Client
+}
+
+// checkHello checks the handshake of the versioned protocol.
+func (c *checker) checkHello(ctx context.Context) Result {
+	result := Result{Scenario: "handshake", Path: "/v1/hello"}
+
+	data, elapsed, err := c.post(ctx, result.Path, bot.Hello{Versions: []int{bot.ProtocolVersion}})
+	result.Elapsed = elapsed
+	if err == nil {
+		err = checkHelloResponse(data)
+	}
+	return result.fail(err)
+}
+
+// checkPlan checks the plan of the turn in the scenario.
+func (c *checker) checkPlan(ctx context.Context, s scenario) Result {
+	result := Result{Scenario: s.name, Path: "/v1/plan"}
+
+	g, err := s.setup(c.config.Seed)
+	if err != nil {
+		return result.fail(fmt.Errorf("scenario setup: %w", err))
+	}
+	player := g.Players[g.Turn()]
+
+	data, elapsed, err := c.post(ctx, result.Path, bot.NewState(g, player, s.phase))
+	result.Elapsed = elapsed
+	if err != nil {
+		return result.fail(err)
+	}
+
+	plan, err := decodePlan(data)
+	if err != nil {
+		return result.fail(err)
+	}
+	return result.fail(plan.Apply(g, player, s.phase))
+}
+
+// checkLegacy checks the single move of the legacy protocol in the scenario.
+// The upgrade is asked for after the attack phase ends.
+func (c *checker) checkLegacy(ctx context.Context, s scenario, path string) Result {
+	result := Result{Scenario: s.name, Path: path}
+
+	g, err := s.setup(c.config.Seed)
+	if err != nil {
+		return result.fail(fmt.Errorf("scenario setup: %w", err))
+	}
+	player := g.Players[g.Turn()]
+	if path == "/ai_upgrade" && player.Attacking() {
+		if err := g.EndAttack(player); err != nil {
+			return result.fail(fmt.Errorf("scenario setup: %w", err))
+		}
+	}
+
+	data, elapsed, err := c.post(ctx, path, g.ToMap())
+	result.Elapsed = elapsed
+	if err != nil {
+		return result.fail(err)
+	}
+
+	plan, err := decodeLegacyAction(data, path)
+	if err != nil {
+		return result.fail(err)
+	}
+	phase := bot.PhaseAttack
+	if path == "/ai_upgrade" {
+		phase = bot.PhaseUpgrade
+	}
+	return result.fail(plan.Apply(g, player, phase))
+}
+
+// checkGame plays the i-th full game of the bot server against the greedy bot,
+// which fails on the first failed request or illegal move of the server.
+func (c *checker) checkGame(ctx context.Context, i int) Result {
+	result := Result{Scenario: fmt.Sprintf("game %d", i+1), Path: "/v1/plan"}
+	if c.config.Legacy {
+		result.Path = "legacy"
+	}
+
+	g, err := game.NewGame(7, 7, 2, c.config.Seed+int64(i))
+	if err != nil {
+		return result.fail(fmt.Errorf("scenario setup: %w", err))
+	}
+
+	server := bot.NewAPI(
+		bot.WithEndpoint(c.config.Endpoint),
+		bot.WithRequestTimeout(c.config.Timeout),
+		bot.WithRetries(0),
+		bot.WithMaxActions(0),
+		bot.WithFallback(""),
+		bot.WithLegacyProtocol(c.config.Legacy),
+		bot.WithHTTPClient(c.client),
+	)
+	greedy, err := bot.New("greedy")
+	if err != nil {
+		return result.fail(err)
+	}
+	strategies := []bot.Strategy{server, greedy}
+	if i%2 == 1 {
+		strategies[0], strategies[1] = greedy, server
+	}
+
+	start := time.Now()
+	for !g.IsFinished() {
+		player := g.Players[g.Turn()]
+		if err := bot.PlayTurn(ctx, g, player, strategies[player.Id()], bot.Budget{}); err != nil {
+			result.Elapsed = time.Since(start)
+			return result.fail(fmt.Errorf("round %d: %w", g.TurnsCount(), err))
+		}
+	}
+	result.Elapsed = time.Since(start)
+	return result
+}
+
+// post sends the payload to the path of the bot server and returns the body of the response with its time.
+// The response must arrive within the timeout with the status 200 OK.
+func (c *checker) post(ctx context.Context, path string, payload any) ([]byte, time.Duration, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.Endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, time.Since(start), fmt.Errorf("no response within %s", c.config.Timeout)
+		}
+		return nil, time.Since(start), err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	elapsed := time.Since(start)
+	if err != nil {
+		return nil, elapsed, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, elapsed, fmt.Errorf("status %d instead of 200", resp.StatusCode)
+	}
+	return data, elapsed, nil
+}
+
+// fail returns the result failed with the error, or passed if it is nil.
+func (r Result) fail(err error) Result {
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
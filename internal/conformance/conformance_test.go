@@ -0,0 +1,114 @@
+package conformance_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Vacym/neighbors-force/internal/conformance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBotServer starts the bot server answering every path with the given body.
+func newBotServer(t *testing.T, bodies map[string]string, delay time.Duration) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		time.Sleep(delay)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// passiveBot ends every phase right away.
+var passiveBot = map[string]string{
+	"/v1/hello":   `{"version": 1, "name": "passive", "capabilities": ["turn_plan"]}`,
+	"/v1/plan":    `{"attacks": [], "upgrades": []}`,
+	"/ai_attack":  `{"attack": null}`,
+	"/ai_upgrade": `{"upgrade": null}`,
+}
+
+func testConfig(endpoint string) conformance.Config {
+	config := conformance.NewConfig()
+	config.Endpoint = endpoint
+	config.Games = 1
+	return config
+}
+
+func TestRun(t *testing.T) {
+	server := newBotServer(t, passiveBot, 0)
+
+	report := conformance.Run(context.Background(), testConfig(server.URL))
+	assert.Zero(t, report.Failed(), report.Results)
+	assert.NotEmpty(t, report.Results)
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	assert.Contains(t, out.String(), "checks passed")
+}
+
+func TestRun_legacy(t *testing.T) {
+	server := newBotServer(t, passiveBot, 0)
+	config := testConfig(server.URL)
+	config.Legacy = true
+
+	report := conformance.Run(context.Background(), config)
+	assert.Zero(t, report.Failed(), report.Results)
+}
+
+func TestRun_failures(t *testing.T) {
+	testCases := []struct {
+		name   string
+		bodies map[string]string
+		delay  time.Duration
+		passed int
+	}{
+		{
+			// The handshake passes and the attacks are ignored in the upgrade phase
+			name:   "illegal attack",
+			passed: 2,
+			bodies: map[string]string{
+				"/v1/hello": passiveBot["/v1/hello"],
+				"/v1/plan":  `{"attacks": [{"from": {"row": 0, "col": 0}, "to": {"row": 0, "col": 0}}], "upgrades": []}`,
+			},
+		},
+		{
+			name: "wrong shape",
+			bodies: map[string]string{
+				"/v1/hello": `{"version": 1, "name": "typo", "capabilities": []}`,
+				"/v1/plan":  `{"attacks": [{"form": {"row": 0, "col": 0}}], "upgrades": null}`,
+			},
+		},
+		{
+			name:   "slow",
+			bodies: passiveBot,
+			delay:  50 * time.Millisecond,
+		},
+		{
+			name:   "missing endpoints",
+			bodies: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			server := newBotServer(t, tc.bodies, tc.delay)
+			config := testConfig(server.URL)
+			config.Timeout = 20 * time.Millisecond
+
+			report := conformance.Run(context.Background(), config)
+			assert.Equal(t, len(report.Results)-tc.passed, report.Failed(), report.Results)
+		})
+	}
+}
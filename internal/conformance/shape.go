@@ -0,0 +1,145 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+var errMalformedResponse = errors.New("malformed response")
+
+// object decodes the JSON object and checks that it has the required fields.
+func object(data []byte, required ...string) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("%w: not a JSON object", errMalformedResponse)
+	}
+
+	for _, name := range required {
+		if _, ok := fields[name]; !ok {
+			return nil, fmt.Errorf("%w: missing field %q", errMalformedResponse, name)
+		}
+	}
+	return fields, nil
+}
+
+// strict decodes the JSON value into the target, rejecting unknown fields, e.g. misspelled ones.
+func strict(name string, data json.RawMessage, target any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return fmt.Errorf("%w: field %q: %v", errMalformedResponse, name, err)
+	}
+	return nil
+}
+
+// isNull reports whether the JSON value is null.
+func isNull(data json.RawMessage) bool {
+	return string(bytes.TrimSpace(data)) == "null"
+}
+
+// checkHelloResponse checks that the handshake response chooses the supported version,
+// names the bot and lists the capabilities including the turn plans.
+func checkHelloResponse(data []byte) error {
+	fields, err := object(data, "version", "name", "capabilities")
+	if err != nil {
+		return err
+	}
+
+	var hello bot.HelloResponse
+	if err := strict("version", fields["version"], &hello.Version); err != nil {
+		return err
+	}
+	if err := strict("name", fields["name"], &hello.Name); err != nil {
+		return err
+	}
+	if isNull(fields["capabilities"]) {
+		return fmt.Errorf("%w: field %q must be a list", errMalformedResponse, "capabilities")
+	}
+	if err := strict("capabilities", fields["capabilities"], &hello.Capabilities); err != nil {
+		return err
+	}
+
+	if hello.Version != bot.ProtocolVersion {
+		return fmt.Errorf("version %d instead of %d", hello.Version, bot.ProtocolVersion)
+	}
+	if hello.Name == "" {
+		return fmt.Errorf("%w: empty name", errMalformedResponse)
+	}
+	for _, capability := range hello.Capabilities {
+		if capability == bot.CapabilityTurnPlan {
+			return nil
+		}
+	}
+	return fmt.Errorf("capability %q is missing", bot.CapabilityTurnPlan)
+}
+
+// decodePlan decodes the plan of the turn, which must have the lists of the attacks and the upgrades.
+func decodePlan(data []byte) (bot.Plan, error) {
+	var plan bot.Plan
+
+	fields, err := object(data, "attacks", "upgrades")
+	if err != nil {
+		return plan, err
+	}
+	for _, name := range []string{"attacks", "upgrades"} {
+		if isNull(fields[name]) {
+			return plan, fmt.Errorf("%w: field %q must be a list", errMalformedResponse, name)
+		}
+	}
+
+	if err := strict("attacks", fields["attacks"], &plan.Attacks); err != nil {
+		return plan, err
+	}
+	if err := strict("upgrades", fields["upgrades"], &plan.Upgrades); err != nil {
+		return plan, err
+	}
+	return plan, nil
+}
+
+// decodeLegacyAction decodes the single move of the legacy protocol as a plan:
+// [[from_row, from_col], [to_row, to_col]] at /ai_attack and [row, col] at /ai_upgrade, or null.
+func decodeLegacyAction(data []byte, path string) (bot.Plan, error) {
+	var plan bot.Plan
+
+	name := "attack"
+	if path == "/ai_upgrade" {
+		name = "upgrade"
+	}
+	fields, err := object(data, name)
+	if err != nil {
+		return plan, err
+	}
+	if isNull(fields[name]) {
+		return plan, nil
+	}
+
+	if name == "attack" {
+		var attack [][]int
+		if err := strict(name, fields[name], &attack); err != nil {
+			return plan, err
+		}
+		if len(attack) != 2 || len(attack[0]) != 2 || len(attack[1]) != 2 {
+			return plan, fmt.Errorf("%w: field %q must be [[row, col], [row, col]]", errMalformedResponse, name)
+		}
+		plan.Attacks = []bot.Attack{{
+			From: game.Coords{Row: attack[0][0], Col: attack[0][1]},
+			To:   game.Coords{Row: attack[1][0], Col: attack[1][1]},
+		}}
+		return plan, nil
+	}
+
+	var upgrade []int
+	if err := strict(name, fields[name], &upgrade); err != nil {
+		return plan, err
+	}
+	if len(upgrade) != 2 {
+		return plan, fmt.Errorf("%w: field %q must be [row, col]", errMalformedResponse, name)
+	}
+	plan.Upgrades = []bot.Upgrade{{Cell: game.Coords{Row: upgrade[0], Col: upgrade[1]}, Levels: 1}}
+	return plan, nil
+}
@@ -8,6 +8,7 @@ var (
 	errSamePlayerCell = errors.New("target cell already belongs to the same player")
 	errNotEnoughPower = errors.New("power of cell must be more then 1 for attack")
 	errIsNotNeighbor  = errors.New("cell can attack only it's neighbor")
+	errForeignCell    = errors.New("target cell was not created by the game package")
 )
 
 // Coords represents row and column indices of a cell.
@@ -43,12 +44,18 @@ type Cell interface {
 	// Upgrade increases the level of the cell by a specified number of levels.
 	upgrade(levels int) error
 
+	// neutralize takes the cell away from its owner.
+	neutralize()
+
 	// CalculatePower calculates the power of the cell considering its neighbors.
 	calculatePower(board *Board)
 
 	// GetNeighbors returns the neighboring cells of the cell on the board.
 	GetNeighbors(board *Board) []Cell
 
+	// clone returns a copy of the cell owned by the given player.
+	clone(owner Player) Cell
+
 	// ToMap converts the cell's information into a map for serialization.
 	toMap() map[string]interface{}
 }
@@ -128,7 +135,10 @@ func (c cell) GetNeighbors(board *Board) []Cell {
 // attack performs an attack on a target cell.
 // It returns true if the player's last cell was destroyed, otherwise false.
 func (c *cell) attack(targetInterface Cell) (bool, error) {
-	target := targetInterface.(*cell)
+	target, ok := targetInterface.(*cell)
+	if !ok {
+		return false, errForeignCell
+	}
 	if c.owner == target.owner {
 		return false, errSamePlayerCell
 	}
@@ -192,11 +202,27 @@ func (c *cell) upgrade(levels int) error {
 	return nil
 }
 
+// neutralize takes the cell away from its owner and resets it to the default state.
+func (c *cell) neutralize() {
+	if c.owner != nil {
+		c.owner.deleteCell()
+	}
+
+	c.owner = nil
+	c.level = 1
+	c.power = 1
+}
+
 // isNeighbor checks if a given cell is a neighbor of the current cell.
 func (c *cell) isNeighbor(cell2 Cell) bool {
 	return IsNeighborCoords(c.Coords(), cell2.Coords())
 }
 
+// clone returns a copy of the cell owned by the given player.
+func (c *cell) clone(owner Player) Cell {
+	return newCellWithParameters(c.coords.Row, c.coords.Col, c.level, c.power, owner)
+}
+
 // toMap converts the cell's information into a map for serialization.
 func (c *cell) toMap() map[string]interface{} {
 	result := map[string]interface{}{
@@ -0,0 +1,47 @@
+package game
+
+// Clone returns an independent deep copy of the game.
+// Changes of the copy do not affect the original game, so it can be used to try moves.
+func (g *Game) Clone() *Game {
+	players := make([]Player, len(g.Players))
+	owners := make(map[Player]Player, len(g.Players))
+	for i, p := range g.Players {
+		players[i] = p.clone()
+		owners[p] = players[i]
+	}
+
+	drawVotes := make([]bool, len(g.drawVotes))
+	copy(drawVotes, g.drawVotes)
+
+	return &Game{
+		Board:      g.Board.clone(owners),
+		Players:    players,
+		turn:       g.turn,
+		winnerId:   g.winnerId,
+		turnsLimit: g.turnsLimit,
+		turnsCount: g.turnsCount,
+		drawVotes:  drawVotes,
+		isDraw:     g.isDraw,
+		seed:       g.seed,
+		started:    g.started,
+	}
+}
+
+// clone returns a deep copy of the board with the owners replaced by the given ones.
+func (b *Board) clone(owners map[Player]Player) *Board {
+	cells := make([][]Cell, len(b.Cells))
+	for i, row := range b.Cells {
+		cells[i] = make([]Cell, len(row))
+		for j, c := range row {
+			if c != nil {
+				cells[i][j] = c.clone(owners[c.Owner()])
+			}
+		}
+	}
+
+	return &Board{
+		rows:  b.rows,
+		cols:  b.cols,
+		Cells: cells,
+	}
+}
@@ -2,6 +2,7 @@ package game
 
 import (
 	"errors"
+	"time"
 )
 
 var (
@@ -13,6 +14,11 @@ var (
 	errInvalidAttackingCell = errors.New("attacking cell is not owned by attacking player")
 	errInvalidUpgradingCell = errors.New("upgrading cell is not owned by player")
 	errGameAlreadyFinished  = errors.New("the game has already finished")
+	errIncorrectLevels      = errors.New("levels to upgrade must be positive")
+	errPlayerNotFound       = errors.New("player with such id does not exist")
+	errPlayerOutOfGame      = errors.New("player has no cells left")
+	errDrawAlreadyOffered   = errors.New("player has already agreed to a draw")
+	errNoDrawOffer          = errors.New("nobody has offered a draw")
 )
 
 // Game represents the core structure that encapsulates the state and logic of the game.
@@ -24,6 +30,10 @@ type Game struct {
 	winnerId   int      // ID of the player who winned, -1 if the game is still on
 	turnsLimit int      // Max count of turns in game
 	turnsCount int      // Current count of turns
+	drawVotes  []bool   // Players who agreed to a draw, by ID
+	isDraw     bool     // The game has finished in a draw
+	seed       int64    // Seed of the board and the bots, 0 for boards not generated from a seed
+	started    bool     // Whether any move has been made
 }
 
 // createGame creates a new game with a given board and players.
@@ -33,6 +43,10 @@ func createGame(rows, cols, numPlayers int, seed int64, isFull bool) (*Game, err
 		return nil, err
 	}
 
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
 	var board *Board
 	if isFull {
 		board, err = NewBoard(rows, cols)
@@ -48,6 +62,7 @@ func createGame(rows, cols, numPlayers int, seed int64, isFull bool) (*Game, err
 		return nil, err
 	}
 
+	game.seed = seed
 	game.placePlayers()
 	game.countPlayersCell()
 
@@ -55,6 +70,7 @@ func createGame(rows, cols, numPlayers int, seed int64, isFull bool) (*Game, err
 }
 
 // NewGame creates a new Game with a random Board and specified number of players.
+// A zero seed is replaced by a random one, which is available by Seed.
 func NewGame(rows, cols int, numPlayers int, seed int64) (*Game, error) {
 	return createGame(rows, cols, numPlayers, seed, false)
 }
@@ -78,6 +94,7 @@ func NewGameWithBoard(board *Board, players []Player) (*Game, error) {
 		turn:       0,
 		winnerId:   -1,
 		turnsLimit: board.cols * board.rows,
+		drawVotes:  make([]bool, len(players)),
 	}
 
 	return game, nil
@@ -103,23 +120,69 @@ func (g *Game) Turn() int {
 	return g.turn
 }
 
+// TurnsCount returns the count of rounds played, where every player makes one turn per round.
+func (g *Game) TurnsCount() int {
+	return g.turnsCount
+}
+
+// TurnsLimit returns the count of rounds after which the game is finished.
+func (g *Game) TurnsLimit() int {
+	return g.turnsLimit
+}
+
+// SetPlayerInfo sets the name, color and controller of the player with the given ID.
+// Empty name and color are replaced with the default ones.
+func (g *Game) SetPlayerInfo(id int, info PlayerInfo) error {
+	if id < 0 || id >= len(g.Players) {
+		return errPlayerNotFound
+	}
+	if err := info.Controller.validate(); err != nil {
+		return err
+	}
+
+	defaultInfo := defaultPlayerInfo(id)
+	if info.Name == "" {
+		info.Name = defaultInfo.Name
+	}
+	if info.Color == "" {
+		info.Color = defaultInfo.Color
+	}
+
+	g.Players[id].setInfo(info)
+	return nil
+}
+
+// Seed returns the seed the game was created with.
+// Bots derive their random choices from it, so that the game can be replayed.
+func (g *Game) Seed() int64 {
+	return g.seed
+}
+
 func (g *Game) IsFinished() bool {
-	return g.winnerId != -1
+	return g.winnerId != -1 || g.isDraw
+}
+
+// IsDraw reports whether the game has finished in a draw.
+func (g *Game) IsDraw() bool {
+	return g.isDraw
 }
 
 func (g *Game) Winner() Player {
-	if g.IsFinished() {
+	if g.winnerId != -1 {
 		return g.Players[g.winnerId]
 	} else {
 		return nil
 	}
 }
 
+// Parameters of the cells the players start with.
+const (
+	startPower = 2
+	startLevel = 1
+)
+
 // placePlayers places players on the board at specific locations.
 func (g *Game) placePlayers() {
-	const startPower = 2
-	const startLevel = 1
-
 	for idx, player := range g.Players {
 		var c Cell
 		switch idx {
@@ -207,6 +270,7 @@ func (g *Game) Attack(player Player, from, to Cell) error {
 	if err := player.attack(); err != nil {
 		return err
 	}
+	g.started = true
 
 	lastCellDestroyed, err := from.attack(to)
 	if lastCellDestroyed {
@@ -227,7 +291,12 @@ func (g *Game) EndAttack(player Player) error {
 		return errNotPlayerTurn
 	}
 
-	return player.endAttack()
+	if err := player.endAttack(); err != nil {
+		return err
+	}
+	g.started = true
+
+	return nil
 }
 
 // Upgrade upgrades a target cell's level by a specified number of levels.
@@ -244,9 +313,13 @@ func (g *Game) Upgrade(player Player, target Cell, levels int) error {
 	if target.Owner() != player {
 		return errInvalidUpgradingCell
 	}
+	if levels < 1 {
+		return errIncorrectLevels
+	}
 	if err := player.upgrade(target, levels); err != nil {
 		return err
 	}
+	g.started = true
 
 	err := target.upgrade(levels)
 
@@ -262,6 +335,7 @@ func (g *Game) EndTurn(player Player) error {
 		return errNotPlayerTurn
 	}
 	player.endUpgrade()
+	g.started = true
 
 	g.Board.calculatePower(player)
 
@@ -270,6 +344,141 @@ func (g *Game) EndTurn(player Player) error {
 	return nil
 }
 
+// Pass skips the whole turn of the player.
+// The player does not earn points for the skipped turn.
+func (g *Game) Pass(player Player) error {
+	if g.IsFinished() {
+		return errGameAlreadyFinished
+	}
+	if player.Id() != g.turn {
+		return errNotPlayerTurn
+	}
+	if err := player.pass(); err != nil {
+		return err
+	}
+	g.started = true
+
+	g.Board.calculatePower(player)
+
+	g.nextTurn()
+
+	return nil
+}
+
+// Resign removes the player from the game and makes all of its cells neutral.
+// A player can resign at any time, not only during its own turn.
+func (g *Game) Resign(player Player) error {
+	if g.IsFinished() {
+		return errGameAlreadyFinished
+	}
+	if player == nil {
+		return errNilPointer
+	}
+	if err := player.resign(); err != nil {
+		return err
+	}
+	g.started = true
+
+	for _, row := range g.Board.Cells {
+		for _, cell := range row {
+			if cell != nil && cell.Owner() == player {
+				cell.neutralize()
+			}
+		}
+	}
+	g.drawVotes[player.Id()] = false
+
+	if lastPlayerId := g.findLastPlayerWithCells(); lastPlayerId != -1 {
+		g.finish(lastPlayerId)
+		return nil
+	}
+
+	if player.Id() == g.turn {
+		g.nextTurn()
+	}
+	g.checkDraw()
+
+	return nil
+}
+
+// OfferDraw records that the player wants to finish the game in a draw.
+// The game finishes in a draw once every player with cells agrees.
+func (g *Game) OfferDraw(player Player) error {
+	if g.IsFinished() {
+		return errGameAlreadyFinished
+	}
+	if player == nil {
+		return errNilPointer
+	}
+	if player.CellsCount() == 0 {
+		return errPlayerOutOfGame
+	}
+	if g.drawVotes[player.Id()] {
+		return errDrawAlreadyOffered
+	}
+
+	g.drawVotes[player.Id()] = true
+	g.checkDraw()
+
+	return nil
+}
+
+// AcceptDraw agrees to a draw offered by another player.
+func (g *Game) AcceptDraw(player Player) error {
+	if !g.isDrawOffered() {
+		return errNoDrawOffer
+	}
+
+	return g.OfferDraw(player)
+}
+
+// DeclineDraw rejects the current draw offer.
+func (g *Game) DeclineDraw(player Player) error {
+	if g.IsFinished() {
+		return errGameAlreadyFinished
+	}
+	if player == nil {
+		return errNilPointer
+	}
+	if player.CellsCount() == 0 {
+		return errPlayerOutOfGame
+	}
+	if !g.isDrawOffered() {
+		return errNoDrawOffer
+	}
+
+	for i := range g.drawVotes {
+		g.drawVotes[i] = false
+	}
+
+	return nil
+}
+
+// isDrawOffered reports whether any player has offered a draw.
+func (g *Game) isDrawOffered() bool {
+	for _, vote := range g.drawVotes {
+		if vote {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDraw finishes the game in a draw if every player with cells agreed to it.
+func (g *Game) checkDraw() {
+	if !g.isDrawOffered() {
+		return
+	}
+
+	for i, player := range g.Players {
+		if player.CellsCount() > 0 && !g.drawVotes[i] {
+			return
+		}
+	}
+
+	g.isDraw = true
+}
+
 // nextTurn advances the turn to the next player with CellsCount != 1.
 func (g *Game) nextTurn() {
 	currentPlayerIndex := g.turn
@@ -326,6 +535,7 @@ func (g *Game) findPlayerWithMaxCells() int {
 
 	for i, player := range g.Players {
 		if player.CellsCount() >= maxCells {
+			maxCells = player.CellsCount()
 			playerId = i
 		}
 	}
@@ -339,11 +549,35 @@ func (g *Game) finish(winnerId int) {
 
 func (g *Game) ToMap() map[string]interface{} {
 	return map[string]interface{}{
-		"board":     g.Board.toMap(),
-		"players":   toPlayerInterfaceSlice(g.Players),
-		"turn":      g.turn,
-		"winner_id": g.winnerId,
+		"board":       g.Board.toMap(),
+		"players":     toPlayerInterfaceSlice(g.Players),
+		"turn":        g.turn,
+		"winner_id":   g.winnerId,
+		"draw":        g.isDraw,
+		"draw_offers": toDrawOffers(g.drawVotes),
+		"seed":        g.seed,
+		"handicaps":   toHandicapSlice(g.Handicaps()),
+	}
+}
+
+// toHandicapSlice converts the handicaps into a slice for serialization.
+func toHandicapSlice(handicaps []Handicap) []interface{} {
+	result := make([]interface{}, len(handicaps))
+	for i, h := range handicaps {
+		result[i] = h.toMap()
 	}
+	return result
+}
+
+// toDrawOffers returns the IDs of the players who agreed to a draw.
+func toDrawOffers(drawVotes []bool) []int {
+	result := make([]int, 0, len(drawVotes))
+	for id, vote := range drawVotes {
+		if vote {
+			result = append(result, id)
+		}
+	}
+	return result
 }
 
 // ToMap converts the game state into a map for serialization.
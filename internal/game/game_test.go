@@ -1,6 +1,7 @@
 package game_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/Vacym/neighbors-force/internal/game"
@@ -123,6 +124,21 @@ func TestGame_NewGame(t *testing.T) {
 	}
 }
 
+func TestGame_Seed(t *testing.T) {
+	seeded, err := game.NewGame(7, 7, 2, 42)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), seeded.Seed())
+
+	replayed, err := game.NewGame(7, 7, 2, 42)
+	require.NoError(t, err)
+	assert.Equal(t, seeded.ToMap(), replayed.ToMap())
+
+	// A random seed is chosen and kept, so that the game can be replayed
+	random, err := game.NewGame(7, 7, 2, 0)
+	require.NoError(t, err)
+	assert.NotZero(t, random.Seed())
+}
+
 func TestGame_NewGameWithBoard(t *testing.T) {
 	board := game.TestBoard()
 
@@ -195,6 +211,38 @@ func TestGame_EndAttack(t *testing.T) {
 	err = g.EndAttack(g.Players[1])
 	assert.NoError(t, err)
 }
+func TestGame_SetPlayerInfo(t *testing.T) {
+	g, err := game.NewGame(5, 5, 2, 0)
+	require.NoError(t, err)
+
+	// Default info
+	assert.Equal(t, "Player 1", g.Players[0].Name())
+	assert.NotEmpty(t, g.Players[0].Color())
+	assert.Equal(t, game.ControllerHuman, g.Players[0].Controller().Kind)
+
+	// Valid info
+	err = g.SetPlayerInfo(1, game.PlayerInfo{
+		Name:       "Bot",
+		Controller: game.Controller{Kind: game.ControllerBot, Level: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bot", g.Players[1].Name())
+	assert.NotEmpty(t, g.Players[1].Color())
+	assert.Equal(t, game.Controller{Kind: game.ControllerBot, Level: 1}, g.Players[1].Controller())
+
+	// Unknown player
+	err = g.SetPlayerInfo(2, game.PlayerInfo{Controller: game.Controller{Kind: game.ControllerHuman}})
+	assert.Error(t, err)
+
+	// Unknown controller
+	err = g.SetPlayerInfo(0, game.PlayerInfo{Controller: game.Controller{Kind: "alien"}})
+	assert.Error(t, err)
+
+	// Negative bot level
+	err = g.SetPlayerInfo(0, game.PlayerInfo{Controller: game.Controller{Kind: game.ControllerBot, Level: -1}})
+	assert.Error(t, err)
+}
+
 func TestGame_ToMap(t *testing.T) {
 	g, err := game.TestGameAttack()
 	require.NoError(t, err)
@@ -203,3 +251,196 @@ func TestGame_ToMap(t *testing.T) {
 	gameMap := g.ToMap()
 	assert.NotEmpty(t, gameMap)
 }
+
+func TestGame_Pass(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	// Not player's turn
+	err = g.Pass(g.Players[1])
+	assert.Error(t, err)
+
+	// Valid pass, no points earned
+	err = g.Pass(g.Players[0])
+	require.NoError(t, err)
+	assert.Equal(t, 0, g.Players[0].Points())
+	assert.Equal(t, 1, g.Turn())
+
+	// Pass after the attack phase
+	err = g.EndAttack(g.Players[1])
+	require.NoError(t, err)
+	err = g.Pass(g.Players[1])
+	assert.Error(t, err)
+}
+
+func TestGame_Resign(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 3)
+	require.NoError(t, err)
+
+	// Resign during own turn passes the turn
+	err = g.Resign(g.Players[0])
+	require.NoError(t, err)
+	assert.True(t, g.Players[0].Resigned())
+	assert.Equal(t, 0, g.Players[0].CellsCount())
+	assert.Equal(t, 1, g.Turn())
+	assert.False(t, g.IsFinished())
+	require.NoError(t, g.CheckInvariants())
+
+	// Resign twice
+	err = g.Resign(g.Players[0])
+	assert.Error(t, err)
+
+	// Resign out of turn leaves the last player
+	err = g.Resign(g.Players[2])
+	require.NoError(t, err)
+	assert.True(t, g.IsFinished())
+	assert.Equal(t, g.Players[1], g.Winner())
+	require.NoError(t, g.CheckInvariants())
+}
+
+func TestGame_Draw(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 3)
+	require.NoError(t, err)
+
+	// Nothing to accept or decline
+	assert.Error(t, g.AcceptDraw(g.Players[1]))
+	assert.Error(t, g.DeclineDraw(g.Players[1]))
+
+	// Declined offer
+	require.NoError(t, g.OfferDraw(g.Players[0]))
+	assert.Error(t, g.OfferDraw(g.Players[0]))
+	require.NoError(t, g.DeclineDraw(g.Players[1]))
+	assert.Error(t, g.AcceptDraw(g.Players[2]))
+
+	// Accepted offer
+	require.NoError(t, g.OfferDraw(g.Players[1]))
+	require.NoError(t, g.AcceptDraw(g.Players[0]))
+	assert.False(t, g.IsFinished())
+	require.NoError(t, g.AcceptDraw(g.Players[2]))
+	assert.True(t, g.IsFinished())
+	assert.True(t, g.IsDraw())
+	assert.Nil(t, g.Winner())
+	require.NoError(t, g.CheckInvariants())
+}
+
+func TestGame_SetHandicap(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	err = g.SetHandicap(1, game.Handicap{Points: 5, Cells: 2, Level: 2, Income: 2})
+	require.NoError(t, err)
+	require.NoError(t, g.CheckInvariants())
+
+	player := g.Players[1]
+	assert.Equal(t, 5, player.Points())
+	assert.Equal(t, 3, player.CellsCount())
+
+	for _, row := range g.Board.Cells {
+		for _, cell := range row {
+			if cell.Owner() == player {
+				assert.Equal(t, 3, cell.Level())
+				// Every cell has an upgraded neighbor of the player
+				assert.Greater(t, cell.Power(), 2)
+			}
+		}
+	}
+
+	// The handicap is given once
+	assert.Error(t, g.SetHandicap(1, game.Handicap{Points: 5}))
+	assert.Equal(t, 5, player.Points())
+
+	// Invalid handicaps
+	assert.Error(t, g.SetHandicap(0, game.Handicap{Cells: -1}))
+	assert.Error(t, g.SetHandicap(2, game.Handicap{}))
+	assert.Error(t, g.SetHandicap(0, game.Handicap{Income: 0.5}))
+
+	// Income multiplier
+	require.NoError(t, g.EndTurn(g.Players[0]))
+	require.NoError(t, g.EndAttack(player))
+	assert.Equal(t, 5+2*3, player.Points())
+
+	// The game has started
+	assert.Error(t, g.SetHandicap(0, game.Handicap{Points: 5}))
+	assert.Equal(t, game.Handicap{}, g.Players[0].Handicap())
+}
+
+func TestGame_SetHandicap_firstAttack(t *testing.T) {
+	testCases := []struct {
+		name     string
+		handicap game.Handicap
+	}{
+		{name: "empty", handicap: game.Handicap{}},
+		{name: "points", handicap: game.Handicap{Points: 5}},
+		{name: "cells", handicap: game.Handicap{Cells: 2}},
+		{name: "levels", handicap: game.Handicap{Cells: 2, Level: 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := game.NewCompleteBoardGame(5, 5, 2)
+			require.NoError(t, err)
+			require.NoError(t, g.SetHandicap(0, tc.handicap))
+
+			player := g.Players[0]
+			attacked := false
+			for _, row := range g.Board.Cells {
+				for _, cell := range row {
+					if cell.Owner() != player || attacked {
+						continue
+					}
+					// The cells keep at least their start power
+					assert.GreaterOrEqual(t, cell.Power(), 2)
+					for _, neighbor := range cell.GetNeighbors(g.Board) {
+						if !attacked && neighbor.Owner() != player {
+							attacked = g.Attack(player, cell, neighbor) == nil
+						}
+					}
+				}
+			}
+			assert.True(t, attacked, "no legal first attack")
+		})
+	}
+}
+
+func TestGame_SetHandicap_replay(t *testing.T) {
+	g, err := game.NewGame(7, 7, 2, 42)
+	require.NoError(t, err)
+	require.NoError(t, g.SetHandicap(1, game.Handicap{Points: 5, Cells: 2, Level: 1, Income: 1.5}))
+
+	// The seed and the handicaps of the serialized game set it up again
+	data, err := json.Marshal(g.ToMap())
+	require.NoError(t, err)
+	var state struct {
+		Seed      int64           `json:"seed"`
+		Handicaps []game.Handicap `json:"handicaps"`
+	}
+	require.NoError(t, json.Unmarshal(data, &state))
+
+	replay, err := game.NewGame(7, 7, 2, state.Seed)
+	require.NoError(t, err)
+	for id, handicap := range state.Handicaps {
+		require.NoError(t, replay.SetHandicap(id, handicap))
+	}
+	assert.Equal(t, g.ToMap(), replay.ToMap())
+}
+
+func TestGame_Clone(t *testing.T) {
+	g, err := game.NewCompleteBoardGame(5, 5, 2)
+	require.NoError(t, err)
+
+	clone := g.Clone()
+	require.NoError(t, clone.CheckInvariants())
+	assert.Equal(t, g.ToMap(), clone.ToMap())
+
+	// Moves in the clone do not change the original game
+	require.NoError(t, clone.EndAttack(clone.Players[0]))
+	require.NoError(t, clone.Upgrade(clone.Players[0], clone.Board.Cells[0][0], 1))
+	require.NoError(t, clone.EndTurn(clone.Players[0]))
+	require.NoError(t, clone.CheckInvariants())
+
+	assert.Equal(t, 0, g.Turn())
+	assert.Equal(t, 1, g.Board.Cells[0][0].Level())
+	assert.Equal(t, 2, clone.Board.Cells[0][0].Level())
+	assert.Equal(t, g.Players[0], g.Board.Cells[0][0].Owner())
+	assert.Equal(t, clone.Players[0], clone.Board.Cells[0][0].Owner())
+}
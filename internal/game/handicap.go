@@ -0,0 +1,153 @@
+package game
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	errIncorrectHandicap  = errors.New("handicap values cannot be negative")
+	errHandicapAlreadySet = errors.New("player already has a handicap")
+	errGameAlreadyStarted = errors.New("handicap cannot be set after the first move")
+)
+
+// Handicap holds the bonuses that a player gets at the start of the game.
+type Handicap struct {
+	Points int     `json:"points"` // Extra points at the start
+	Cells  int     `json:"cells"`  // Extra cells around the start cell
+	Level  int     `json:"level"`  // Extra levels of the start cells
+	Income float64 `json:"income"` // Multiplier of points earned per turn, 0 is treated as 1
+}
+
+// validate checks that the handicap does not take anything away from the player.
+func (h Handicap) validate() error {
+	if h.Points < 0 || h.Cells < 0 || h.Level < 0 {
+		return errIncorrectHandicap
+	}
+	if h.Income != 0 && h.Income < 1 {
+		return errIncorrectHandicap
+	}
+	return nil
+}
+
+// income returns the points earned per turn for the given count of cells.
+func (h Handicap) income(cellsCount int) int {
+	if h.Income == 0 {
+		return cellsCount
+	}
+	return int(math.Floor(float64(cellsCount) * h.Income))
+}
+
+// toMap converts the handicap into a map for serialization.
+func (h Handicap) toMap() map[string]interface{} {
+	income := h.Income
+	if income == 0 {
+		income = 1
+	}
+
+	return map[string]interface{}{
+		"points": h.Points,
+		"cells":  h.Cells,
+		"level":  h.Level,
+		"income": income,
+	}
+}
+
+// SetHandicap gives the player with the given ID its starting bonuses.
+// The power of the player's cells only grows with the extra levels, so that
+// the player can still attack on the first turn.
+// It fails after the first move of the game and if the player already has a handicap.
+func (g *Game) SetHandicap(id int, handicap Handicap) error {
+	if g.IsFinished() {
+		return errGameAlreadyFinished
+	}
+	if g.started {
+		return errGameAlreadyStarted
+	}
+	if id < 0 || id >= len(g.Players) {
+		return errPlayerNotFound
+	}
+	if err := handicap.validate(); err != nil {
+		return err
+	}
+
+	player := g.Players[id]
+	if player.Handicap() != (Handicap{}) {
+		return errHandicapAlreadySet
+	}
+	player.setHandicap(handicap)
+	player.addPoints(handicap.Points)
+
+	ownedCells := g.claimNearestCells(player, handicap.Cells)
+	if handicap.Level == 0 {
+		return nil
+	}
+
+	for _, c := range ownedCells {
+		c.upgrade(handicap.Level)
+	}
+	for _, c := range ownedCells {
+		power := max(c.Power(), startPower)
+		c.calculatePower(g.Board)
+		if c.Power() < power {
+			g.Board.Cells[c.Row()][c.Col()] = newCellWithParameters(c.Row(), c.Col(), c.Level(), power, player)
+		}
+	}
+
+	return nil
+}
+
+// Handicaps returns the starting bonuses of the players by their IDs.
+// Together with the seed, they are enough to set up the game again for a replay.
+func (g *Game) Handicaps() []Handicap {
+	result := make([]Handicap, len(g.Players))
+	for id, player := range g.Players {
+		result[id] = player.Handicap()
+	}
+	return result
+}
+
+// claimNearestCells gives the player up to count free cells closest to its cells
+// and returns all cells owned by the player.
+func (g *Game) claimNearestCells(player Player, count int) []Cell {
+	var owned []Cell
+	visited := make(map[Coords]bool)
+
+	for _, row := range g.Board.Cells {
+		for _, c := range row {
+			if c != nil && c.Owner() == player {
+				owned = append(owned, c)
+				visited[c.Coords()] = true
+			}
+		}
+	}
+
+	// Breadth-first search from the player's cells
+	queue := make([]Cell, len(owned))
+	copy(queue, owned)
+
+	for len(queue) > 0 && count > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, neighbor := range current.GetNeighbors(g.Board) {
+			if count == 0 {
+				break
+			}
+			if visited[neighbor.Coords()] || neighbor.Owner() != nil {
+				continue
+			}
+			visited[neighbor.Coords()] = true
+
+			claimed := newCellWithParameters(neighbor.Row(), neighbor.Col(), startLevel, startPower, player)
+			g.Board.Cells[neighbor.Row()][neighbor.Col()] = claimed
+			player.addCell()
+			count--
+
+			owned = append(owned, claimed)
+			queue = append(queue, claimed)
+		}
+	}
+
+	return owned
+}
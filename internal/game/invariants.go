@@ -0,0 +1,97 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errInvariantViolated = errors.New("game invariant violated")
+
+// CheckInvariants verifies that the game state is internally consistent.
+// It returns an error describing the first violated invariant, or nil.
+func (g *Game) CheckInvariants() error {
+	if g.Board == nil {
+		return fmt.Errorf("%w: board is nil", errInvariantViolated)
+	}
+
+	owned := make(map[Player]int, len(g.Players))
+
+	for i, row := range g.Board.Cells {
+		for j, c := range row {
+			if c == nil {
+				continue
+			}
+			if c.Row() != i || c.Col() != j {
+				return fmt.Errorf("%w: cell at (%d, %d) has coords (%d, %d)", errInvariantViolated, i, j, c.Row(), c.Col())
+			}
+			if c.Power() < 0 {
+				return fmt.Errorf("%w: cell (%d, %d) has negative power %d", errInvariantViolated, i, j, c.Power())
+			}
+			if c.Level() < 1 {
+				return fmt.Errorf("%w: cell (%d, %d) has level %d", errInvariantViolated, i, j, c.Level())
+			}
+			if c.Owner() != nil {
+				owned[c.Owner()]++
+			}
+		}
+	}
+
+	playersWithCells := 0
+	for i, player := range g.Players {
+		if player.Id() != i {
+			return fmt.Errorf("%w: player at index %d has id %d", errInvariantViolated, i, player.Id())
+		}
+		if player.Points() < 0 {
+			return fmt.Errorf("%w: player %d has negative points %d", errInvariantViolated, i, player.Points())
+		}
+		if player.CellsCount() != owned[player] {
+			return fmt.Errorf("%w: player %d counts %d cells, board has %d", errInvariantViolated, i, player.CellsCount(), owned[player])
+		}
+		if player.Resigned() && player.CellsCount() > 0 {
+			return fmt.Errorf("%w: resigned player %d owns cells", errInvariantViolated, i)
+		}
+		if player.CellsCount() > 0 {
+			playersWithCells++
+		}
+		delete(owned, player)
+	}
+	if len(owned) != 0 {
+		return fmt.Errorf("%w: board has cells owned by unknown players", errInvariantViolated)
+	}
+
+	if g.turn < 0 || g.turn >= len(g.Players) {
+		return fmt.Errorf("%w: turn %d is out of range", errInvariantViolated, g.turn)
+	}
+
+	if !g.IsFinished() {
+		if playersWithCells < 2 {
+			return fmt.Errorf("%w: game is on with %d players owning cells", errInvariantViolated, playersWithCells)
+		}
+		if g.Players[g.turn].CellsCount() == 0 {
+			return fmt.Errorf("%w: player %d moves without cells", errInvariantViolated, g.turn)
+		}
+		return nil
+	}
+
+	if g.isDraw {
+		if g.winnerId != -1 {
+			return fmt.Errorf("%w: draw game has winner %d", errInvariantViolated, g.winnerId)
+		}
+		return nil
+	}
+
+	if g.winnerId < 0 || g.winnerId >= len(g.Players) {
+		return fmt.Errorf("%w: winner %d is out of range", errInvariantViolated, g.winnerId)
+	}
+	winner := g.Players[g.winnerId]
+	if winner.CellsCount() == 0 {
+		return fmt.Errorf("%w: winner %d owns no cells", errInvariantViolated, g.winnerId)
+	}
+	for _, player := range g.Players {
+		if player.CellsCount() > winner.CellsCount() {
+			return fmt.Errorf("%w: player %d owns more cells than winner %d", errInvariantViolated, player.Id(), g.winnerId)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package game_test
+
+import (
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGame_CheckInvariants(t *testing.T) {
+	g, err := game.NewGame(9, 9, 4, 1)
+	require.NoError(t, err)
+	assert.NoError(t, g.CheckInvariants())
+
+	// Test players claim more cells than they own on the board
+	g, err = game.TestGameAttack()
+	require.NoError(t, err)
+	assert.Error(t, g.CheckInvariants())
+}
+
+// FuzzGame plays random sequences of actions on random boards
+// and checks the game invariants after every action.
+func FuzzGame(f *testing.F) {
+	f.Add(int64(1), uint8(2), uint8(2), uint8(2), []byte{0, 1, 2, 3, 0, 0, 1, 2, 2, 1, 3})
+	f.Add(int64(2), uint8(4), uint8(3), uint8(4), []byte{0, 5, 3, 0, 7, 1, 0, 9, 2, 1, 2, 4, 3})
+	f.Add(int64(3), uint8(1), uint8(1), uint8(3), []byte{3, 3, 3, 3, 3, 3, 0, 0, 0, 1, 1, 1})
+
+	f.Fuzz(func(t *testing.T, seed int64, rowsHalf, colsHalf, numPlayers uint8, actions []byte) {
+		rows := 2*int(rowsHalf%6) + 3
+		cols := 2*int(colsHalf%6) + 3
+		players := int(numPlayers%3) + 2
+
+		g, err := game.NewGame(rows, cols, players, seed)
+		require.NoError(t, err)
+		require.NoError(t, g.CheckInvariants())
+
+		var cells []game.Cell
+		for _, row := range g.Board.Cells {
+			for _, c := range row {
+				if c != nil {
+					cells = append(cells, c)
+				}
+			}
+		}
+
+		next := func() int {
+			if len(actions) == 0 {
+				return 0
+			}
+			b := actions[0]
+			actions = actions[1:]
+			return int(b)
+		}
+
+		for len(actions) > 0 {
+			op := next()
+
+			// Most actions are made by the current player, the rest by a random one
+			player := g.Players[g.Turn()]
+			if op&0x80 != 0 {
+				player = g.Players[next()%len(g.Players)]
+			}
+
+			switch op % 8 {
+			case 0:
+				from := cells[next()%len(cells)]
+				neighbors := from.GetNeighbors(g.Board)
+				to := cells[next()%len(cells)]
+				if len(neighbors) > 0 && op&0x40 == 0 {
+					to = neighbors[next()%len(neighbors)]
+				}
+				g.Attack(player, from, to)
+			case 1:
+				g.EndAttack(player)
+			case 2:
+				target := cells[next()%len(cells)]
+				levels := int(int8(next())) % 4
+				g.Upgrade(player, target, levels)
+			case 3:
+				g.EndTurn(player)
+			case 4:
+				g.Pass(player)
+			case 5:
+				// Resignations are rare, otherwise games end too soon
+				if op&0x20 != 0 && op&0x10 != 0 {
+					g.Resign(player)
+				}
+			case 6:
+				g.OfferDraw(player)
+			case 7:
+				if op&0x20 != 0 {
+					g.AcceptDraw(player)
+				} else {
+					g.DeclineDraw(player)
+				}
+			}
+
+			require.NoError(t, g.CheckInvariants())
+		}
+	})
+}
@@ -1,14 +1,93 @@
 package game
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
 
 var (
 	errAttackTurnExpired     = errors.New("attack turn has expired")
 	errUpgradeTurnNotReached = errors.New("upgrade time has not been reached")
 	errNotEnoughPoints       = errors.New("not enough points to upgrade")
 	errAttackAlreadyFinished = errors.New("attack already finished")
+	errIncorrectController   = errors.New("incorrect player controller")
+	errAlreadyResigned       = errors.New("player has already resigned")
+)
+
+// defaultColors are the colors of the players by their ID.
+var defaultColors = [...]string{"#00BFFF", "#9DF020", "#FFA500", "#FF69B4"}
+
+// ControllerKind describes who makes moves for the player.
+type ControllerKind string
+
+const (
+	ControllerHuman    ControllerKind = "human"    // Moves are made by a person
+	ControllerBot      ControllerKind = "bot"      // Moves are made by a built-in bot
+	ControllerExternal ControllerKind = "external" // Moves are made by an external bot server
 )
 
+// Controller describes who controls the player.
+// A built-in bot is chosen by its name, or by its level if the name is empty.
+// An external bot is asked at its endpoint, or at the server default if the endpoint is empty.
+type Controller struct {
+	Kind     ControllerKind `json:"kind"`
+	Level    int            `json:"level"`    // Difficulty level, used only by built-in bots
+	Bot      string         `json:"bot"`      // Name of the bot, used only by built-in bots
+	Endpoint string         `json:"endpoint"` // Base URL of the bot server, used only by external bots
+	Playing  string         `json:"-"`        // Bot played in the current turn, set by the server for the adaptive bots
+}
+
+// validate checks that the controller describes a known kind of player.
+func (c Controller) validate() error {
+	switch c.Kind {
+	case ControllerHuman:
+		return nil
+	case ControllerExternal:
+		if c.Endpoint == "" {
+			return nil
+		}
+		u, err := url.Parse(c.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errIncorrectController
+		}
+		return nil
+	case ControllerBot:
+		if c.Level < 0 {
+			return errIncorrectController
+		}
+		return nil
+	}
+	return errIncorrectController
+}
+
+// toMap converts the controller into a map for serialization.
+func (c Controller) toMap() map[string]interface{} {
+	result := map[string]interface{}{
+		"kind": c.Kind,
+	}
+	if c.Kind == ControllerBot {
+		result["level"] = c.Level
+		if c.Bot != "" {
+			result["bot"] = c.Bot
+		}
+		if c.Playing != "" {
+			result["playing"] = c.Playing
+		}
+	}
+	if c.Kind == ControllerExternal && c.Endpoint != "" {
+		result["endpoint"] = c.Endpoint
+	}
+	return result
+}
+
+// PlayerInfo holds the metadata of the player that does not affect the game rules.
+type PlayerInfo struct {
+	Name       string     `json:"name"`
+	Color      string     `json:"color"`
+	Controller Controller `json:"controller"`
+}
+
 // Player represents a player in the game.
 type Player interface {
 	// Id returns the ID of the player.
@@ -20,18 +99,45 @@ type Player interface {
 	// CellsCount returns number of cells owned by the player
 	CellsCount() int
 
+	// Name returns the display name of the player.
+	Name() string
+
+	// Color returns the display color of the player.
+	Color() string
+
+	// Controller returns who makes moves for the player.
+	Controller() Controller
+
+	// Resigned reports whether the player has left the game.
+	Resigned() bool
+
+	// Attacking reports whether the player is in the attack phase of its turn.
+	Attacking() bool
+
+	// Handicap returns the starting bonuses of the player.
+	Handicap() Handicap
+
 	// attack initiates an attack for the player's turn.
 	attack() error
 
 	// endAttack concludes the attack phase for the player.
 	endAttack() error
 
+	// pass concludes the player's turn without earning points.
+	pass() error
+
+	// resign marks the player as left the game.
+	resign() error
+
 	// upgrade upgrades a cell owned by the player.
 	upgrade(cell Cell, levels int) error
 
 	// endUpgrade concludes the upgrade phase for the player's turn.
 	endUpgrade()
 
+	// addPoints gives the player extra points.
+	addPoints(points int)
+
 	// addCell increments the player's cell count.
 	addCell()
 
@@ -39,6 +145,15 @@ type Player interface {
 	// It returns true if there are still cells and the player remaining, otherwise false.
 	deleteCell() bool
 
+	// setInfo replaces the player's metadata.
+	setInfo(info PlayerInfo)
+
+	// setHandicap replaces the player's starting bonuses.
+	setHandicap(handicap Handicap)
+
+	// clone returns an independent copy of the player.
+	clone() Player
+
 	// toMap converts the player's information into a map for serialization.
 	toMap() map[string]interface{}
 }
@@ -49,6 +164,9 @@ type player struct {
 	points     int  // Points that can be spent on upgrading cells or attacking
 	cellsCount int  // Count of cells, owned user
 	attacking  bool // phase of player turn
+	resigned   bool // player has left the game
+	info       PlayerInfo
+	handicap   Handicap
 }
 
 // newPlayer creates a new Player with the given ID.
@@ -56,6 +174,16 @@ func newPlayer(id int) *player {
 	return &player{
 		id:        id,
 		attacking: true,
+		info:      defaultPlayerInfo(id),
+	}
+}
+
+// defaultPlayerInfo returns the metadata of a human player with the given ID.
+func defaultPlayerInfo(id int) PlayerInfo {
+	return PlayerInfo{
+		Name:       fmt.Sprintf("Player %d", id+1),
+		Color:      defaultColors[id%len(defaultColors)],
+		Controller: Controller{Kind: ControllerHuman},
 	}
 }
 
@@ -74,6 +202,46 @@ func (p *player) CellsCount() int {
 	return p.cellsCount
 }
 
+// Name returns the display name of the player.
+func (p *player) Name() string {
+	return p.info.Name
+}
+
+// Color returns the display color of the player.
+func (p *player) Color() string {
+	return p.info.Color
+}
+
+// Controller returns who makes moves for the player.
+func (p *player) Controller() Controller {
+	return p.info.Controller
+}
+
+// Resigned reports whether the player has left the game.
+func (p *player) Resigned() bool {
+	return p.resigned
+}
+
+// Attacking reports whether the player is in the attack phase of its turn.
+func (p *player) Attacking() bool {
+	return p.attacking
+}
+
+// setInfo replaces the player's metadata.
+func (p *player) setInfo(info PlayerInfo) {
+	p.info = info
+}
+
+// Handicap returns the starting bonuses of the player.
+func (p *player) Handicap() Handicap {
+	return p.handicap
+}
+
+// setHandicap replaces the player's starting bonuses.
+func (p *player) setHandicap(handicap Handicap) {
+	p.handicap = handicap
+}
+
 // attack performs an attack for the player.
 func (p *player) attack() error {
 	if !p.attacking {
@@ -94,9 +262,30 @@ func (p *player) endAttack() error {
 	return nil
 }
 
+// pass ends the player's turn without earning points.
+// The turn can only be passed in the attack phase.
+func (p *player) pass() error {
+	if !p.attacking {
+		return errAttackAlreadyFinished
+	}
+
+	return nil
+}
+
+// resign marks the player as left the game.
+func (p *player) resign() error {
+	if p.resigned {
+		return errAlreadyResigned
+	}
+
+	p.resigned = true
+	p.attacking = true
+	return nil
+}
+
 // countPoints calculates the points based on the player's cell count.
 func (p *player) countPoints() {
-	p.points += p.cellsCount
+	p.points += p.handicap.income(p.cellsCount)
 }
 
 // upgrade upgrades a cell owned by the player.
@@ -126,6 +315,11 @@ func (p *player) endUpgrade() {
 	p.attacking = true
 }
 
+// addPoints gives the player extra points.
+func (p *player) addPoints(points int) {
+	p.points += points
+}
+
 // addCell increments the count of cells owned by the player.
 func (p *player) addCell() {
 	p.cellsCount++
@@ -138,6 +332,12 @@ func (p *player) deleteCell() bool {
 	return p.cellsCount == 0
 }
 
+// clone returns an independent copy of the player.
+func (p *player) clone() Player {
+	copied := *p
+	return &copied
+}
+
 // toMap converts the player's information into a map for serialization.
 func (p *player) toMap() map[string]interface{} {
 	return map[string]interface{}{
@@ -145,5 +345,10 @@ func (p *player) toMap() map[string]interface{} {
 		"points":      p.points,
 		"cells_count": p.cellsCount,
 		"attacking":   p.attacking,
+		"resigned":    p.resigned,
+		"name":        p.info.Name,
+		"color":       p.info.Color,
+		"controller":  p.info.Controller.toMap(),
+		"handicap":    p.handicap.toMap(),
 	}
 }
@@ -0,0 +1,57 @@
+package neural
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var errIncorrectRecord = errors.New("incorrect record")
+
+// Record is a line of the self-play dataset: the serialized game before the move
+// of the player and the final result of the player. The other fields of the line are ignored.
+type Record struct {
+	Position Position `json:"position"`
+	Player   int      `json:"player"` // ID of the player to move
+	Result   float64  `json:"result"` // 1 for a win, 0.5 for a draw and 0 for a loss
+}
+
+// ReadSamples reads the dataset of JSON lines and returns the samples of its positions.
+// Every position must be played on the board of the given size.
+func ReadSamples(r io.Reader, rows, cols int) ([]Sample, error) {
+	var samples []Sample
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", errIncorrectRecord, line, err)
+		}
+		if record.Position.Board.Rows != rows || record.Position.Board.Cols != cols {
+			return nil, fmt.Errorf("%w: line %d: board %dx%d instead of %dx%d", errIncorrectRecord, line,
+				record.Position.Board.Rows, record.Position.Board.Cols, rows, cols)
+		}
+		if !record.Position.fits() {
+			return nil, fmt.Errorf("%w: line %d: cells do not fit the board", errIncorrectRecord, line)
+		}
+		if record.Result < 0 || record.Result > 1 {
+			return nil, fmt.Errorf("%w: line %d: result %v", errIncorrectRecord, line, record.Result)
+		}
+
+		samples = append(samples, Sample{
+			Features: Features(record.Position, record.Player),
+			Result:   record.Result,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
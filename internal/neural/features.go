@@ -0,0 +1,132 @@
+package neural
+
+import (
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+// Scales bring the features to roughly the unit range.
+const (
+	levelScale  = 5
+	powerScale  = 10
+	pointsScale = 20
+)
+
+// globalFeatures is the count of the features of the whole position after the planes of the cells.
+const globalFeatures = 4
+
+// PositionCell is a cell of the position as serialized by the game.
+type PositionCell struct {
+	Level   int  `json:"level"`
+	Power   int  `json:"power"`
+	OwnerId *int `json:"owner_id"`
+}
+
+// PositionPlayer is a player of the position as serialized by the game.
+type PositionPlayer struct {
+	Id         int `json:"id"`
+	Points     int `json:"points"`
+	CellsCount int `json:"cells_count"`
+}
+
+// Position is the part of the serialized game the features are extracted from.
+type Position struct {
+	Board struct {
+		Rows  int               `json:"rows"`
+		Cols  int               `json:"cols"`
+		Cells [][]*PositionCell `json:"cells"`
+	} `json:"board"`
+	Players []PositionPlayer `json:"players"`
+}
+
+// PositionOf returns the position of the game, equal to the one decoded from its serialization.
+func PositionOf(g *game.Game) Position {
+	var pos Position
+	pos.Board.Rows, pos.Board.Cols = g.Board.Rows(), g.Board.Cols()
+	pos.Board.Cells = make([][]*PositionCell, len(g.Board.Cells))
+	for i, row := range g.Board.Cells {
+		pos.Board.Cells[i] = make([]*PositionCell, len(row))
+		for j, cell := range row {
+			if cell == nil {
+				continue
+			}
+			pc := &PositionCell{Level: cell.Level(), Power: cell.Power()}
+			if owner := cell.Owner(); owner != nil {
+				id := owner.Id()
+				pc.OwnerId = &id
+			}
+			pos.Board.Cells[i][j] = pc
+		}
+	}
+
+	for _, p := range g.Players {
+		pos.Players = append(pos.Players, PositionPlayer{Id: p.Id(), Points: p.Points(), CellsCount: p.CellsCount()})
+	}
+	return pos
+}
+
+// fits reports whether the cells of the position fit its board.
+func (pos Position) fits() bool {
+	if len(pos.Board.Cells) != pos.Board.Rows {
+		return false
+	}
+	for _, row := range pos.Board.Cells {
+		if len(row) > pos.Board.Cols {
+			return false
+		}
+	}
+	return true
+}
+
+// FeaturesCount returns the count of the features of the positions on the boards of the size.
+func FeaturesCount(rows, cols int) int {
+	return 4*rows*cols + globalFeatures
+}
+
+// Features returns the features of the position from the point of view of the player:
+// the planes of own and enemy cells, the planes of their levels and powers signed by the owner,
+// followed by the points and the shares of the territory.
+func Features(pos Position, player int) []float64 {
+	rows, cols := pos.Board.Rows, pos.Board.Cols
+	plane := rows * cols
+	features := make([]float64, FeaturesCount(rows, cols))
+
+	cellsTotal := 0
+	for i, row := range pos.Board.Cells {
+		for j, cell := range row {
+			if cell == nil {
+				continue
+			}
+			cellsTotal++
+			if cell.OwnerId == nil {
+				continue
+			}
+
+			sign := -1.0
+			k := i*cols + j
+			if *cell.OwnerId == player {
+				sign = 1
+				features[k] = 1
+			} else {
+				features[plane+k] = 1
+			}
+			features[2*plane+k] = sign * float64(cell.Level) / levelScale
+			features[3*plane+k] = sign * float64(cell.Power) / powerScale
+		}
+	}
+
+	global := features[4*plane:]
+	for _, p := range pos.Players {
+		if p.Id == player {
+			global[0] += float64(p.Points) / pointsScale
+			global[2] += float64(p.CellsCount)
+		} else {
+			global[1] += float64(p.Points) / pointsScale
+			global[3] += float64(p.CellsCount)
+		}
+	}
+	if cellsTotal > 0 {
+		global[2] /= float64(cellsTotal)
+		global[3] /= float64(cellsTotal)
+	}
+	return features
+}
@@ -0,0 +1,261 @@
+// Package neural scores positions of the game with a small neural network trained from self-play.
+package neural
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+)
+
+var (
+	errIncorrectNetwork     = errors.New("incorrect network")
+	errIncorrectTrainConfig = errors.New("incorrect training config")
+	errNoSamples            = errors.New("no samples to train on")
+)
+
+// Layer is a fully connected layer of the network.
+type Layer struct {
+	Weights [][]float64 `json:"weights"` // Weights[i][j] connects the j-th input to the i-th output
+	Biases  []float64   `json:"biases"`
+}
+
+// Network is a multilayer perceptron with tanh hidden layers and a sigmoid output,
+// which estimates the probability that the player to evaluate for wins.
+type Network struct {
+	Rows   int     `json:"rows"` // Size of the boards the network evaluates
+	Cols   int     `json:"cols"`
+	Layers []Layer `json:"layers"`
+}
+
+// NewNetwork creates the network for the boards of the size with the given sizes of the hidden layers.
+// The weights are initialized randomly from the seed.
+func NewNetwork(rows, cols int, hidden []int, seed int64) *Network {
+	r := rand.New(rand.NewSource(seed))
+	sizes := append(append([]int{FeaturesCount(rows, cols)}, hidden...), 1)
+
+	n := &Network{Rows: rows, Cols: cols}
+	for l := 1; l < len(sizes); l++ {
+		in, out := sizes[l-1], sizes[l]
+		scale := math.Sqrt(1 / float64(in))
+
+		layer := Layer{
+			Weights: make([][]float64, out),
+			Biases:  make([]float64, out),
+		}
+		for i := range layer.Weights {
+			layer.Weights[i] = make([]float64, in)
+			for j := range layer.Weights[i] {
+				layer.Weights[i][j] = r.NormFloat64() * scale
+			}
+		}
+		n.Layers = append(n.Layers, layer)
+	}
+	return n
+}
+
+// LoadNetwork reads the network from JSON.
+func LoadNetwork(r io.Reader) (*Network, error) {
+	n := &Network{}
+	if err := json.NewDecoder(r).Decode(n); err != nil {
+		return nil, err
+	}
+	if err := n.validate(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Save writes the network as JSON.
+func (n *Network) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(n)
+}
+
+// validate checks that the sizes of the layers match each other and the boards.
+func (n *Network) validate() error {
+	if len(n.Layers) == 0 {
+		return fmt.Errorf("%w: no layers", errIncorrectNetwork)
+	}
+
+	in := FeaturesCount(n.Rows, n.Cols)
+	for l, layer := range n.Layers {
+		if len(layer.Weights) == 0 || len(layer.Weights) != len(layer.Biases) {
+			return fmt.Errorf("%w: layer %d has %d outputs and %d biases", errIncorrectNetwork, l, len(layer.Weights), len(layer.Biases))
+		}
+		for _, weights := range layer.Weights {
+			if len(weights) != in {
+				return fmt.Errorf("%w: layer %d expects %d inputs", errIncorrectNetwork, l, in)
+			}
+		}
+		in = len(layer.Weights)
+	}
+	if in != 1 {
+		return fmt.Errorf("%w: %d outputs", errIncorrectNetwork, in)
+	}
+	return nil
+}
+
+// Evaluate returns the estimated probability of the win for the features.
+func (n *Network) Evaluate(features []float64) float64 {
+	activations := n.forward(features)
+	return activations[len(activations)-1][0]
+}
+
+// forward returns the outputs of every layer, starting with the input.
+func (n *Network) forward(features []float64) [][]float64 {
+	activations := make([][]float64, 0, len(n.Layers)+1)
+	activations = append(activations, features)
+
+	in := features
+	for l, layer := range n.Layers {
+		out := make([]float64, len(layer.Weights))
+		for i, weights := range layer.Weights {
+			sum := layer.Biases[i]
+			for j, w := range weights {
+				sum += w * in[j]
+			}
+			if l == len(n.Layers)-1 {
+				out[i] = sigmoid(sum)
+			} else {
+				out[i] = math.Tanh(sum)
+			}
+		}
+		activations = append(activations, out)
+		in = out
+	}
+	return activations
+}
+
+// Sample is a position with the result of the player it is evaluated for.
+type Sample struct {
+	Features []float64
+	Result   float64 // 1 for a win, 0.5 for a draw and 0 for a loss
+}
+
+// TrainConfig describes the training of the network.
+type TrainConfig struct {
+	Epochs       int     // Count of passes over the samples
+	BatchSize    int     // Count of samples per update of the weights
+	LearningRate float64 // Size of the update
+	Seed         int64   // Seed of the order of the samples
+}
+
+// NewTrainConfig returns the default training config.
+func NewTrainConfig() TrainConfig {
+	return TrainConfig{
+		Epochs:       20,
+		BatchSize:    32,
+		LearningRate: 0.05,
+		Seed:         1,
+	}
+}
+
+// validate checks that the config describes a possible training.
+func (c TrainConfig) validate() error {
+	if c.Epochs < 1 || c.BatchSize < 1 || c.LearningRate <= 0 {
+		return errIncorrectTrainConfig
+	}
+	return nil
+}
+
+// Train fits the network to the samples by minibatch gradient descent on the cross-entropy
+// and returns the mean loss of the last epoch.
+func (n *Network) Train(samples []Sample, config TrainConfig) (float64, error) {
+	if err := config.validate(); err != nil {
+		return 0, err
+	}
+	if len(samples) == 0 {
+		return 0, errNoSamples
+	}
+
+	r := rand.New(rand.NewSource(config.Seed))
+	order := make([]int, len(samples))
+	for i := range order {
+		order[i] = i
+	}
+
+	loss := 0.0
+	for epoch := 0; epoch < config.Epochs; epoch++ {
+		r.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+		loss = 0
+		for start := 0; start < len(order); start += config.BatchSize {
+			end := min(start+config.BatchSize, len(order))
+			gradient := n.zeroGradient()
+			for _, i := range order[start:end] {
+				loss += n.backward(samples[i], gradient)
+			}
+			n.update(gradient, config.LearningRate/float64(end-start))
+		}
+		loss /= float64(len(samples))
+	}
+	return loss, nil
+}
+
+// zeroGradient returns the gradient of the shape of the network filled with zeros.
+func (n *Network) zeroGradient() []Layer {
+	gradient := make([]Layer, len(n.Layers))
+	for l, layer := range n.Layers {
+		gradient[l] = Layer{
+			Weights: make([][]float64, len(layer.Weights)),
+			Biases:  make([]float64, len(layer.Biases)),
+		}
+		for i := range layer.Weights {
+			gradient[l].Weights[i] = make([]float64, len(layer.Weights[i]))
+		}
+	}
+	return gradient
+}
+
+// backward adds the gradient of the loss of the sample to the gradient and returns the loss.
+func (n *Network) backward(sample Sample, gradient []Layer) float64 {
+	activations := n.forward(sample.Features)
+	output := activations[len(activations)-1][0]
+
+	// The derivative of the cross-entropy through the sigmoid
+	delta := []float64{output - sample.Result}
+	for l := len(n.Layers) - 1; l >= 0; l-- {
+		in := activations[l]
+		for i, d := range delta {
+			gradient[l].Biases[i] += d
+			for j, a := range in {
+				gradient[l].Weights[i][j] += d * a
+			}
+		}
+		if l == 0 {
+			break
+		}
+
+		// Through the tanh of the previous layer
+		prev := make([]float64, len(in))
+		for j, a := range in {
+			sum := 0.0
+			for i, d := range delta {
+				sum += n.Layers[l].Weights[i][j] * d
+			}
+			prev[j] = sum * (1 - a*a)
+		}
+		delta = prev
+	}
+
+	const eps = 1e-12
+	return -(sample.Result*math.Log(output+eps) + (1-sample.Result)*math.Log(1-output+eps))
+}
+
+// update moves the weights against the gradient.
+func (n *Network) update(gradient []Layer, rate float64) {
+	for l, layer := range n.Layers {
+		for i := range layer.Weights {
+			layer.Biases[i] -= rate * gradient[l].Biases[i]
+			for j := range layer.Weights[i] {
+				layer.Weights[i][j] -= rate * gradient[l].Weights[i][j]
+			}
+		}
+	}
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
@@ -0,0 +1,106 @@
+package neural_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/game"
+	"github.com/Vacym/neighbors-force/internal/neural"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatures(t *testing.T) {
+	g, err := game.NewGame(5, 5, 2, 1)
+	require.NoError(t, err)
+
+	features := neural.Features(neural.PositionOf(g), 0)
+	require.Len(t, features, neural.FeaturesCount(5, 5))
+
+	// The features of the serialized game are equal to the ones of the game
+	data, err := json.Marshal(g.ToMap())
+	require.NoError(t, err)
+	var pos neural.Position
+	require.NoError(t, json.Unmarshal(data, &pos))
+	assert.Equal(t, features, neural.Features(pos, 0))
+
+	// The planes of the own and enemy cells swap for the opponent
+	opponent := neural.Features(pos, 1)
+	plane := 5 * 5
+	assert.Equal(t, features[:plane], opponent[plane:2*plane])
+	assert.Equal(t, features[plane:2*plane], opponent[:plane])
+}
+
+// samples returns the samples where the result is the sign of the first feature.
+func samples(count int) []neural.Sample {
+	size := neural.FeaturesCount(3, 3)
+	result := make([]neural.Sample, count)
+	for i := range result {
+		features := make([]float64, size)
+		features[0] = float64(i%2*2 - 1)
+		result[i] = neural.Sample{Features: features, Result: float64(i % 2)}
+	}
+	return result
+}
+
+func TestNetwork_Train(t *testing.T) {
+	network := neural.NewNetwork(3, 3, []int{8}, 1)
+	data := samples(64)
+
+	config := neural.NewTrainConfig()
+	config.Epochs = 200
+	loss, err := network.Train(data, config)
+	require.NoError(t, err)
+	assert.Less(t, loss, 0.2)
+	assert.Greater(t, network.Evaluate(data[1].Features), 0.8)
+	assert.Less(t, network.Evaluate(data[0].Features), 0.2)
+
+	// Impossible trainings
+	_, err = network.Train(nil, config)
+	assert.Error(t, err)
+	for _, broken := range []func(*neural.TrainConfig){
+		func(c *neural.TrainConfig) { c.Epochs = 0 },
+		func(c *neural.TrainConfig) { c.BatchSize = 0 },
+		func(c *neural.TrainConfig) { c.LearningRate = -1 },
+	} {
+		config := neural.NewTrainConfig()
+		broken(&config)
+		_, err = network.Train(data, config)
+		assert.Error(t, err)
+	}
+}
+
+func TestNetwork_Save(t *testing.T) {
+	network := neural.NewNetwork(3, 3, []int{4, 2}, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, network.Save(&buf))
+	loaded, err := neural.LoadNetwork(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, network, loaded)
+
+	_, err = neural.LoadNetwork(strings.NewReader(`{"rows": 3, "cols": 3, "layers": [{"weights": [[1]], "biases": [0]}]}`))
+	assert.Error(t, err)
+}
+
+func TestReadSamples(t *testing.T) {
+	g, err := game.NewGame(5, 5, 2, 1)
+	require.NoError(t, err)
+	position, err := json.Marshal(g.ToMap())
+	require.NoError(t, err)
+
+	dataset := fmt.Sprintf(`{"position": %s, "player": 1, "phase": "attack", "result": 1}`+"\n\n", position)
+	samples, err := neural.ReadSamples(strings.NewReader(dataset), 5, 5)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, neural.Features(neural.PositionOf(g), 1), samples[0].Features)
+	assert.Equal(t, 1.0, samples[0].Result)
+
+	_, err = neural.ReadSamples(strings.NewReader(dataset), 7, 7)
+	assert.Error(t, err)
+	_, err = neural.ReadSamples(strings.NewReader(`{"position": `), 5, 5)
+	assert.Error(t, err)
+}
@@ -1,11 +1,62 @@
 package proxyserver
 
+import "time"
+
 type Config struct {
-	BindAddrProxy string `toml:"bind_addr_proxy"`
-	BindAddrApi   string `toml:"bind_addr_api"`
-	BindAddrHtml  string `toml:"bind_addr_html"`
-	SessionKey    string `toml:"session_key"`
-	LogLevel      string `toml:"log_level"`
+	BindAddrProxy string        `toml:"bind_addr_proxy"`
+	BindAddrApi   string        `toml:"bind_addr_api"`
+	BindAddrHtml  string        `toml:"bind_addr_html"`
+	SessionKey    string        `toml:"session_key"`
+	SessionIdle   time.Duration `toml:"session_idle_timeout"` // Time after which an idle user and its game are dropped, 0 for never
+	LogLevel      string        `toml:"log_level"`
+	BotMoveBudget time.Duration `toml:"bot_move_budget"` // Time a bot may think over a single move
+	BotTurnBudget time.Duration `toml:"bot_turn_budget"` // Time a bot may think over a whole turn
+	BotRestBudget time.Duration `toml:"bot_rest_budget"` // Time the bots may think over the rest of a game the user is out of
+
+	BotAPIEndpoints  []string      `toml:"bot_api_endpoints"`   // Allowed bot servers, the first one is the default
+	BotAPITimeout    time.Duration `toml:"bot_api_timeout"`     // Time of a single request to a bot server
+	BotAPIRetries    int           `toml:"bot_api_retries"`     // Extra attempts after a failed request
+	BotAPIMaxActions int           `toml:"bot_api_max_actions"` // Max moves of an external bot per turn, 0 means no limit
+	BotAPIFallback   string        `toml:"bot_api_fallback"`    // Built-in bot used when a bot server fails, empty for none
+	BotAPILegacy     bool          `toml:"bot_api_legacy"`      // Ask bot servers for single moves at the legacy endpoints
+
+	BotTrace      bool    `toml:"bot_trace"`      // Record the decisions of the bots, see /game/bot_trace
+	HintBot       string  `toml:"hint_bot"`       // Built-in bot that suggests moves to the users, "chain" if empty
+	AdaptiveSkill float64 `toml:"adaptive_skill"` // Skill the "adaptive" bots start at in a session, from 0 (random) to 3 (mcts)
+
+	OpeningBook         string `toml:"opening_book"`           // File of the opening book made by cmd/book, empty for none
+	OpeningBookLevel    int    `toml:"opening_book_level"`     // Min level of the built-in bots that consult the opening book
+	OpeningBookMinPlays int    `toml:"opening_book_min_plays"` // Plays of a book move needed to trust it
+
+	// The games of the server are seeded, so the search of the "mcts" bot is bounded
+	// by the iterations and the move budget of the bots, not by a time budget of its own
+	MCTSIterations   int    `toml:"mcts_iterations"`    // Max search iterations of the "mcts" bot per move, 0 for default
+	MCTSPlayoutTurns int    `toml:"mcts_playout_turns"` // Turns the "mcts" bot plays out after the current one, 0 for default
+	MCTSPlayout      string `toml:"mcts_playout"`       // Bot making the moves of the playouts, empty for default
+
+	NeuralNetwork string `toml:"neural_network"` // File of the value network made by cmd/train, registers the "neural" bot
+
+	BotProfiles string         `toml:"bot_profiles"` // File of personality profiles of heuristic bots, see configs/profiles-example.toml
+	Engines     []EngineConfig `toml:"engines"`      // Bots running as local processes
+}
+
+// EngineConfig describes a bot running as a local process.
+type EngineConfig struct {
+	Name        string         `toml:"name"`         // Name the bot is selected by
+	Description string         `toml:"description"`  // Short human-readable description
+	Path        string         `toml:"path"`         // Path of the executable
+	Args        []string       `toml:"args"`         // Arguments of the executable
+	MoveTimeout time.Duration  `toml:"move_timeout"` // Time the engine may think over a single move, 0 for default
+	Sandbox     *SandboxConfig `toml:"sandbox"`      // Limits of an untrusted engine, nil to run it without isolation
+}
+
+// SandboxConfig limits the resources of an untrusted engine. Zero values mean the defaults.
+type SandboxConfig struct {
+	CPUTime   time.Duration `toml:"cpu_time"`   // CPU time of the process over its whole life
+	MemoryMB  int64         `toml:"memory_mb"`  // Megabytes of the address space
+	FileMB    int64         `toml:"file_mb"`    // Megabytes of a file written by the process
+	OpenFiles int           `toml:"open_files"` // Count of open file descriptors
+	OutputMB  int64         `toml:"output_mb"`  // Megabytes written to stdout over the whole life
 }
 
 func NewConfig() *Config {
@@ -13,5 +64,19 @@ func NewConfig() *Config {
 		BindAddrProxy: ":8080",
 		BindAddrApi:   ":8081",
 		BindAddrHtml:  ":8082",
+		SessionIdle:   time.Hour,
+		BotMoveBudget: 2 * time.Second,
+		BotTurnBudget: 10 * time.Second,
+		BotRestBudget: 30 * time.Second,
+
+		BotAPITimeout:    time.Second,
+		BotAPIRetries:    1,
+		BotAPIMaxActions: 100,
+		BotAPIFallback:   "greedy",
+
+		AdaptiveSkill: 1,
+
+		OpeningBookLevel:    2,
+		OpeningBookMinPlays: 3,
 	}
 }
@@ -0,0 +1,91 @@
+package tune
+
+import (
+	"context"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+// BookConfig describes a self-play run that learns an opening book.
+type BookConfig struct {
+	Config          // Count of games, size of the boards, seed and workers
+	Bots   []string // Names of the bots by their seats
+	Rounds int      // Count of the first rounds of every game added to the book
+	Boards int      // Count of distinct boards the games cycle through, so that the openings repeat
+}
+
+// NewBookConfig returns the default config of learning an opening book.
+func NewBookConfig() BookConfig {
+	config := NewConfig()
+	config.Games = 200
+	return BookConfig{
+		Config: config,
+		Bots:   []string{"greedy", "random"},
+		Rounds: 3,
+		Boards: 10,
+	}
+}
+
+// validate checks that the config describes a possible run.
+func (c BookConfig) validate() error {
+	if err := c.Config.validate(); err != nil {
+		return err
+	}
+	if len(c.Bots) < 2 || c.Rounds < 1 || c.Boards < 1 {
+		return errIncorrectConfig
+	}
+	for _, name := range c.Bots {
+		if _, err := bot.New(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LearnBook plays the self-play games of the config in parallel and adds the moves of their
+// first rounds to the book with the results of the players who made them.
+func LearnBook(ctx context.Context, config BookConfig, book *bot.Book) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+
+	return parallel(config.Workers, config.Games, func(i int) error {
+		return learnGame(ctx, config, book, i)
+	})
+}
+
+// learnGame plays the i-th game of the config and adds its opening to the book.
+// The bots play the same boards in every cycle, but make their random choices from the game number.
+func learnGame(ctx context.Context, config BookConfig, book *bot.Book, i int) error {
+	g, err := game.NewGame(config.Rows, config.Cols, len(config.Bots), config.Seed+int64(i%config.Boards))
+	if err != nil {
+		return err
+	}
+
+	recorders := make([]*bot.BookRecorder, len(config.Bots))
+	for seat, name := range config.Bots {
+		strategy, err := bot.New(name)
+		if err != nil {
+			return err
+		}
+		bot.Seed(strategy, config.Seed+int64(i), seat)
+		recorders[seat] = bot.NewBookRecorder(strategy, config.Rounds)
+	}
+
+	for !g.IsFinished() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		player := g.Players[g.Turn()]
+		if err := bot.PlayTurn(ctx, g, player, recorders[player.Id()], bot.Budget{}); err != nil {
+			return err
+		}
+	}
+
+	for seat, recorder := range recorders {
+		recorder.Commit(book, result(g, seat))
+	}
+	return nil
+}
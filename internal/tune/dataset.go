@@ -0,0 +1,191 @@
+package tune
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+// DatasetConfig describes a self-play run that exports the positions of the games.
+type DatasetConfig struct {
+	Config          // Count of games, size of the boards, seed and workers
+	Bots   []string // Names of the bots by their seats
+}
+
+// NewDatasetConfig returns the default config of exporting a dataset.
+func NewDatasetConfig() DatasetConfig {
+	config := NewConfig()
+	config.Games = 100
+	return DatasetConfig{
+		Config: config,
+		Bots:   []string{"greedy", "greedy"},
+	}
+}
+
+// validate checks that the config describes a possible run.
+func (c DatasetConfig) validate() error {
+	if c.Games < 1 || c.Workers < 1 || len(c.Bots) < 2 {
+		return errIncorrectConfig
+	}
+	for _, name := range c.Bots {
+		if _, err := bot.New(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Move is the move chosen by a bot. Both moves are nil for the end of the phase.
+type Move struct {
+	Attack  *bot.Attack  `json:"attack,omitempty"`
+	Upgrade *bot.Upgrade `json:"upgrade,omitempty"`
+}
+
+// Record is a line of the dataset: a position, the move the player chose in it and the outcome of the game.
+type Record struct {
+	Game     int                    `json:"game"`      // Number of the game in the run
+	Position map[string]interface{} `json:"position"`  // Game before the move, as serialized by the game
+	Player   int                    `json:"player"`    // ID of the player to move
+	Phase    bot.Phase              `json:"phase"`     // Phase of the turn
+	Move     Move                   `json:"move"`      // Move chosen by the player
+	WinnerId int                    `json:"winner_id"` // ID of the winner of the game, -1 for a draw
+	Result   float64                `json:"result"`    // Result of the player: 1 for a win, 0.5 for a draw and 0 for a loss
+}
+
+// ExportDataset plays the self-play games of the config in parallel and writes their records as JSON lines.
+// The i-th game is played on the board and with the random choices seeded from the seed of the config plus i,
+// and the games are written in order, so the same config always produces the same dataset.
+func ExportDataset(ctx context.Context, config DatasetConfig, w io.Writer) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+
+	out := &orderedWriter{w: w, pending: make(map[int][]byte)}
+	return parallel(config.Workers, config.Games, func(i int) error {
+		records, err := exportGame(ctx, config, i)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return out.write(i, buf.Bytes())
+	})
+}
+
+// exportGame plays the i-th game of the config and returns the records of its moves.
+func exportGame(ctx context.Context, config DatasetConfig, i int) ([]Record, error) {
+	seed := config.Seed + int64(i)
+	g, err := game.NewGame(config.Rows, config.Cols, len(config.Bots), seed)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	recorders := make([]bot.Strategy, len(config.Bots))
+	for seat, name := range config.Bots {
+		strategy, err := bot.New(name)
+		if err != nil {
+			return nil, err
+		}
+		bot.Seed(strategy, seed, seat)
+		recorders[seat] = recorder{Strategy: strategy, game: i, records: &records}
+	}
+
+	for !g.IsFinished() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		player := g.Players[g.Turn()]
+		if err := bot.PlayTurn(ctx, g, player, recorders[player.Id()], bot.Budget{}); err != nil {
+			return nil, err
+		}
+	}
+
+	winnerId := -1
+	if winner := g.Winner(); winner != nil {
+		winnerId = winner.Id()
+	}
+	for j := range records {
+		records[j].WinnerId = winnerId
+		records[j].Result = result(g, records[j].Player)
+	}
+	return records, nil
+}
+
+// recorder wraps a strategy and records its moves with the positions they were chosen in.
+type recorder struct {
+	bot.Strategy
+	game    int
+	records *[]Record
+}
+
+// PlanAttack returns the attack of the wrapped strategy and records it.
+func (r recorder) PlanAttack(ctx context.Context, g *game.Game, player game.Player) (*bot.Attack, error) {
+	position := g.ToMap()
+	attack, err := r.Strategy.PlanAttack(ctx, g, player)
+	if err == nil {
+		r.add(position, player, bot.PhaseAttack, Move{Attack: attack})
+	}
+	return attack, err
+}
+
+// PlanUpgrade returns the upgrade of the wrapped strategy and records it.
+func (r recorder) PlanUpgrade(ctx context.Context, g *game.Game, player game.Player) (*bot.Upgrade, error) {
+	position := g.ToMap()
+	upgrade, err := r.Strategy.PlanUpgrade(ctx, g, player)
+	if err == nil {
+		r.add(position, player, bot.PhaseUpgrade, Move{Upgrade: upgrade})
+	}
+	return upgrade, err
+}
+
+// add appends the record of the move, the outcome is filled in at the end of the game.
+func (r recorder) add(position map[string]interface{}, player game.Player, phase bot.Phase, move Move) {
+	*r.records = append(*r.records, Record{
+		Game:     r.game,
+		Position: position,
+		Player:   player.Id(),
+		Phase:    phase,
+		Move:     move,
+	})
+}
+
+// orderedWriter writes the outputs of the parallel jobs in the order of their numbers.
+type orderedWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	next    int
+	pending map[int][]byte
+}
+
+// write writes the output of the i-th job as soon as the outputs of the previous jobs are written.
+func (o *orderedWriter) write(i int, data []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.pending[i] = data
+	for {
+		data, ok := o.pending[o.next]
+		if !ok {
+			return nil
+		}
+		delete(o.pending, o.next)
+		o.next++
+
+		if _, err := o.w.Write(data); err != nil {
+			return err
+		}
+	}
+}
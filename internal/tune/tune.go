@@ -0,0 +1,304 @@
+// Package tune tunes the weights of the heuristic bots by self-play.
+package tune
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/game"
+)
+
+var errIncorrectConfig = errors.New("incorrect tuning config")
+
+// Config describes a tuning run.
+type Config struct {
+	Iterations int     // Count of SPSA iterations
+	Games      int     // Count of games per evaluation
+	Rows, Cols int     // Size of the boards
+	Seed       int64   // Seed of the boards and the perturbations
+	Workers    int     // Count of games played in parallel
+	Baseline   string  // Name of the bot the result is compared with
+	Checkpoint string  // File the progress is saved to after every iteration, empty for none
+	StepSize   float64 // Initial size of the weight update, "a" of SPSA
+	Delta      float64 // Initial size of the weight perturbation, "c" of SPSA
+}
+
+// NewConfig returns the default config.
+func NewConfig() Config {
+	return Config{
+		Iterations: 50,
+		Games:      20,
+		Rows:       7,
+		Cols:       7,
+		Seed:       1,
+		Workers:    runtime.NumCPU(),
+		Baseline:   "greedy",
+		StepSize:   0.2,
+		Delta:      0.1,
+	}
+}
+
+// validate checks that the config describes a possible run.
+func (c Config) validate() error {
+	if c.Iterations < 0 || c.Games < 1 || c.Workers < 1 || c.StepSize <= 0 || c.Delta <= 0 {
+		return errIncorrectConfig
+	}
+	if _, err := bot.New(c.Baseline); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Checkpoint is the progress of a tuning run.
+type Checkpoint struct {
+	Iteration int         `json:"iteration"` // Count of finished iterations
+	Profile   bot.Profile `json:"profile"`   // Weights after the finished iterations
+}
+
+// Tune improves the weights of the profile by SPSA: every iteration the weights are perturbed
+// in a random direction both ways, the perturbed profiles play each other,
+// and the weights move towards the winner. The run resumes from the checkpoint if it exists.
+// The progress is reported to the log after every iteration.
+func Tune(ctx context.Context, config Config, profile bot.Profile, log io.Writer) (bot.Profile, error) {
+	if err := config.validate(); err != nil {
+		return profile, err
+	}
+
+	checkpoint := Checkpoint{Profile: profile}
+	if err := loadCheckpoint(config.Checkpoint, &checkpoint); err != nil {
+		return profile, err
+	}
+
+	for k := checkpoint.Iteration; k < config.Iterations; k++ {
+		if err := ctx.Err(); err != nil {
+			return checkpoint.Profile, err
+		}
+
+		// Gains of SPSA with the usual exponents
+		a := config.StepSize / math.Pow(float64(k+1)+0.1*float64(config.Iterations), 0.602)
+		c := config.Delta / math.Pow(float64(k+1), 0.101)
+
+		r := rand.New(rand.NewSource(config.Seed + int64(k)))
+		theta := weights(checkpoint.Profile)
+		direction := make([]float64, len(theta))
+		plus := make([]float64, len(theta))
+		minus := make([]float64, len(theta))
+		for i := range theta {
+			direction[i] = float64(2*r.Intn(2) - 1)
+			plus[i] = clamp(theta[i] + c*direction[i])
+			minus[i] = clamp(theta[i] - c*direction[i])
+		}
+
+		plusProfile := withWeights(checkpoint.Profile, plus)
+		minusProfile := withWeights(checkpoint.Profile, minus)
+		score, err := Match(ctx, config, profileFactory(plusProfile), profileFactory(minusProfile), config.Seed+int64(k)*int64(config.Games))
+		if err != nil {
+			return checkpoint.Profile, err
+		}
+
+		// Score is between 0 and 1, the gradient of the win rate of the "plus" profile is estimated from it
+		for i := range theta {
+			gradient := (2*score - 1) / (2 * c * direction[i])
+			theta[i] = clamp(theta[i] + a*gradient)
+		}
+
+		checkpoint.Iteration = k + 1
+		checkpoint.Profile = withWeights(checkpoint.Profile, theta)
+		if err := saveCheckpoint(config.Checkpoint, checkpoint); err != nil {
+			return checkpoint.Profile, err
+		}
+
+		p := checkpoint.Profile
+		fmt.Fprintf(log, "iteration %d/%d: plus scored %.2f, aggression %.3f expansion %.3f defense %.3f risk %.3f\n",
+			checkpoint.Iteration, config.Iterations, score, p.Aggression, p.Expansion, p.Defense, p.Risk)
+	}
+	return checkpoint.Profile, nil
+}
+
+// WinRate returns the score of the profile against the baseline bot of the config.
+// The games are played on the boards that follow the boards of the tuning iterations,
+// so that the profile is not measured on the boards it has been tuned on.
+func WinRate(ctx context.Context, config Config, profile bot.Profile) (float64, error) {
+	if err := config.validate(); err != nil {
+		return 0, err
+	}
+
+	baseline := func() bot.Strategy {
+		strategy, _ := bot.New(config.Baseline)
+		return strategy
+	}
+	seed := config.Seed + int64(config.Iterations)*int64(config.Games)
+	return Match(ctx, config, profileFactory(profile), baseline, seed)
+}
+
+// profileFactory returns the function creating the heuristic bot with the profile.
+func profileFactory(profile bot.Profile) func() bot.Strategy {
+	return func() bot.Strategy { return bot.NewProfile(profile) }
+}
+
+// Match plays the games of the config between the bots in parallel and returns the score of the first bot:
+// 1 for a win, 0.5 for a draw and 0 for a loss, averaged over the games.
+// The bots swap seats every game and the boards are seeded from the seed.
+// Every game is played by new bots created by the functions and seeded from the board,
+// so the bots may keep state between moves.
+func Match(ctx context.Context, config Config, first, second func() bot.Strategy, seed int64) (float64, error) {
+	scores := make([]float64, config.Games)
+	err := parallel(config.Workers, config.Games, func(i int) error {
+		score, err := playGame(ctx, config, first, second, seed+int64(i/2), i%2 == 1)
+		scores[i] = score
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0.0
+	for _, score := range scores {
+		total += score
+	}
+	return total / float64(config.Games), nil
+}
+
+// parallel runs the jobs with the given count of workers and returns the first error.
+func parallel(workers, count int, job func(i int) error) error {
+	jobs := make(chan int)
+	errs := make(chan error, count)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := job(i); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+// playGame plays a game between the new bots and returns the score of the first one.
+func playGame(ctx context.Context, config Config, first, second func() bot.Strategy, seed int64, swapped bool) (float64, error) {
+	g, err := game.NewGame(config.Rows, config.Cols, 2, seed)
+	if err != nil {
+		return 0, err
+	}
+
+	strategies := []bot.Strategy{first(), second()}
+	firstId := 0
+	if swapped {
+		strategies[0], strategies[1] = strategies[1], strategies[0]
+		firstId = 1
+	}
+	for seat, strategy := range strategies {
+		bot.Seed(strategy, seed, seat)
+	}
+
+	for !g.IsFinished() {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		player := g.Players[g.Turn()]
+		if err := bot.PlayTurn(ctx, g, player, strategies[player.Id()], bot.Budget{}); err != nil {
+			return 0, err
+		}
+	}
+
+	return result(g, firstId), nil
+}
+
+// result returns the result of the player of the finished game: 1 for a win, 0.5 for a draw and 0 for a loss.
+func result(g *game.Game, id int) float64 {
+	switch winner := g.Winner(); {
+	case winner == nil:
+		return 0.5
+	case winner.Id() == id:
+		return 1
+	}
+	return 0
+}
+
+// WriteProfile writes the profile as TOML with the comment on top.
+func WriteProfile(w io.Writer, profile bot.Profile, comment string) error {
+	if _, err := fmt.Fprintf(w, "# %s\n\n", comment); err != nil {
+		return err
+	}
+	return toml.NewEncoder(w).Encode(map[string]bot.Profile{profile.Name: profile})
+}
+
+// weights returns the tuned weights of the profile.
+func weights(p bot.Profile) []float64 {
+	return []float64{p.Aggression, p.Expansion, p.Defense, p.Risk}
+}
+
+// withWeights returns the profile with the tuned weights.
+func withWeights(p bot.Profile, w []float64) bot.Profile {
+	p.Aggression, p.Expansion, p.Defense, p.Risk = w[0], w[1], w[2], w[3]
+	return p
+}
+
+// clamp limits the weight to the range of the profiles.
+func clamp(weight float64) float64 {
+	return math.Max(0, math.Min(1, weight))
+}
+
+// loadCheckpoint reads the progress from the file, unless it does not exist.
+func loadCheckpoint(path string, checkpoint *Checkpoint) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	name := checkpoint.Profile.Name
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return err
+	}
+	checkpoint.Profile.Name = name
+	return nil
+}
+
+// saveCheckpoint writes the progress to the file atomically, so that it is not lost on interrupt.
+func saveCheckpoint(path string, checkpoint Checkpoint) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
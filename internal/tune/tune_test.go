@@ -0,0 +1,183 @@
+package tune_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Vacym/neighbors-force/internal/bot"
+	"github.com/Vacym/neighbors-force/internal/neural"
+	"github.com/Vacym/neighbors-force/internal/tune"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(t *testing.T) tune.Config {
+	t.Helper()
+
+	config := tune.NewConfig()
+	config.Iterations = 2
+	config.Games = 4
+	config.Rows, config.Cols = 5, 5
+	config.Workers = 2
+	config.Checkpoint = filepath.Join(t.TempDir(), "checkpoint.json")
+	return config
+}
+
+// newBot returns the function creating the bot with the name.
+func newBot(t *testing.T, name string) func() bot.Strategy {
+	return func() bot.Strategy {
+		strategy, err := bot.New(name)
+		require.NoError(t, err)
+		return strategy
+	}
+}
+
+func TestMatch(t *testing.T) {
+	config := testConfig(t)
+
+	// The bots swap seats, so a bot against itself scores evenly
+	score, err := tune.Match(context.Background(), config, newBot(t, "greedy"), newBot(t, "greedy"), config.Seed)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, score)
+
+	// The bots with state play their own games, seeded from the boards
+	score, err = tune.Match(context.Background(), config, newBot(t, "chain"), newBot(t, "random"), config.Seed)
+	require.NoError(t, err)
+	again, err := tune.Match(context.Background(), config, newBot(t, "chain"), newBot(t, "random"), config.Seed)
+	require.NoError(t, err)
+	assert.Equal(t, score, again)
+}
+
+func TestTune(t *testing.T) {
+	config := testConfig(t)
+	start := bot.BuiltinProfiles()[0]
+
+	profile, err := tune.Tune(context.Background(), config, start, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, start.Name, profile.Name)
+	for _, weight := range []float64{profile.Aggression, profile.Expansion, profile.Defense, profile.Risk} {
+		assert.GreaterOrEqual(t, weight, 0.0)
+		assert.LessOrEqual(t, weight, 1.0)
+	}
+
+	// The run is resumed from the checkpoint, so no more iterations are played
+	resumed, err := tune.Tune(context.Background(), config, start, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, profile, resumed)
+
+	var out bytes.Buffer
+	require.NoError(t, tune.WriteProfile(&out, profile, "tuned"))
+	loaded, err := bot.LoadProfiles(&out)
+	require.NoError(t, err)
+	require.Len(t, loaded, 1)
+	assert.Equal(t, profile, loaded[0])
+}
+
+func TestTuneIncorrectConfig(t *testing.T) {
+	config := testConfig(t)
+	config.Baseline = "unknown"
+
+	_, err := tune.Tune(context.Background(), config, bot.BuiltinProfiles()[0], &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func TestLearnBook(t *testing.T) {
+	config := tune.NewBookConfig()
+	config.Games = 4
+	config.Rows, config.Cols = 5, 5
+	config.Workers = 2
+	config.Boards = 1
+
+	book := bot.NewBook()
+	require.NoError(t, tune.LearnBook(context.Background(), config, book))
+	assert.NotZero(t, book.Len())
+
+	config.Bots = []string{"greedy", "unknown"}
+	assert.Error(t, tune.LearnBook(context.Background(), config, book))
+}
+
+func TestExportDataset(t *testing.T) {
+	config := tune.NewDatasetConfig()
+	config.Games = 4
+	config.Rows, config.Cols = 5, 5
+	config.Bots = []string{"greedy", "random"}
+	config.Workers = 1
+
+	var first bytes.Buffer
+	require.NoError(t, tune.ExportDataset(context.Background(), config, &first))
+
+	// The dataset does not depend on the parallelism
+	config.Workers = 3
+	var second bytes.Buffer
+	require.NoError(t, tune.ExportDataset(context.Background(), config, &second))
+	assert.Equal(t, first.String(), second.String())
+
+	lines := strings.Split(strings.TrimSpace(first.String()), "\n")
+	require.NotEmpty(t, lines)
+	games := make(map[int]bool)
+	for _, line := range lines {
+		var record tune.Record
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		games[record.Game] = true
+
+		assert.Contains(t, []bot.Phase{bot.PhaseAttack, bot.PhaseUpgrade}, record.Phase)
+		assert.False(t, record.Move.Attack != nil && record.Move.Upgrade != nil)
+		switch record.WinnerId {
+		case -1:
+			assert.Equal(t, 0.5, record.Result)
+		case record.Player:
+			assert.Equal(t, 1.0, record.Result)
+		default:
+			assert.Equal(t, 0.0, record.Result)
+		}
+	}
+	assert.Len(t, games, config.Games)
+
+	// The records are the training samples of the value network
+	samples, err := neural.ReadSamples(&first, config.Rows, config.Cols)
+	require.NoError(t, err)
+	assert.Len(t, samples, len(lines))
+
+	config.Bots = []string{"greedy"}
+	assert.Error(t, tune.ExportDataset(context.Background(), config, &bytes.Buffer{}))
+}
+
+// TestNeuralBenchmark trains the value network on seeded self-play games
+// and measures the neural bot against the greedy one on seeded boards.
+func TestNeuralBenchmark(t *testing.T) {
+	if testing.Short() {
+		t.Skip("trains a network")
+	}
+
+	dataset := tune.NewDatasetConfig()
+	dataset.Games = 40
+	dataset.Rows, dataset.Cols = 5, 5
+	dataset.Bots = []string{"random", "greedy"} // Both bots win some games
+	dataset.Workers = 4
+
+	var records bytes.Buffer
+	require.NoError(t, tune.ExportDataset(context.Background(), dataset, &records))
+	samples, err := neural.ReadSamples(&records, dataset.Rows, dataset.Cols)
+	require.NoError(t, err)
+
+	network := neural.NewNetwork(dataset.Rows, dataset.Cols, []int{16}, 1)
+	_, err = network.Train(samples, neural.NewTrainConfig())
+	require.NoError(t, err)
+
+	config := testConfig(t)
+	config.Games = 20
+	config.Seed = dataset.Seed + int64(dataset.Games) // Boards the network was not trained on
+	neuralBot := func() bot.Strategy { return bot.NewNeural(network) }
+
+	score, err := tune.Match(context.Background(), config, neuralBot, newBot(t, "greedy"), config.Seed)
+	require.NoError(t, err)
+	again, err := tune.Match(context.Background(), config, neuralBot, newBot(t, "greedy"), config.Seed)
+	require.NoError(t, err)
+	assert.Equal(t, score, again)
+	assert.Greater(t, score, 0.0)
+	t.Logf("neural bot scores %.2f against the greedy one in %d games", score, config.Games)
+}
@@ -0,0 +1,47 @@
+"""Greedy bot speaking the engine protocol over stdin and stdout.
+
+See docs/engine-protocol.md.
+"""
+import json
+import sys
+
+from game import Game
+
+
+def main() -> None:
+    game = Game()
+    state = None
+
+    for line in sys.stdin:
+        command, _, argument = line.strip().partition(' ')
+
+        if command == 'nfe':
+            answer('id name python-greedy')
+            answer('nfeok')
+        elif command == 'position':
+            state = json.loads(argument)
+        elif command == 'go' and state is not None:
+            plan = game.plan(state)
+            if state['phase'] == 'attack' and plan['attacks']:
+                attack = plan['attacks'][0]
+                answer('bestmove attack {} {} {} {}'.format(
+                    attack['from']['row'], attack['from']['col'],
+                    attack['to']['row'], attack['to']['col']))
+            elif state['phase'] == 'upgrade' and plan['upgrades']:
+                upgrade = plan['upgrades'][0]
+                answer('bestmove upgrade {} {} {}'.format(
+                    upgrade['cell']['row'], upgrade['cell']['col'],
+                    upgrade['levels']))
+            else:
+                answer('bestmove none')
+        elif command == 'quit':
+            return
+
+
+def answer(line: str) -> None:
+    sys.stdout.write(line + '\n')
+    sys.stdout.flush()
+
+
+if __name__ == '__main__':
+    main()
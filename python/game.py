@@ -1,5 +1,7 @@
 from random import randint
 
+MAX_PLAN_ACTIONS = 100
+
 
 class Game:
     def reinit(self, game: dict) -> None:
@@ -8,6 +10,68 @@ class Game:
         self.points = game['players'][self.player]['points']
         self.actions = {'attack': None, 'upgrade': None}
 
+    def plan(self, state: dict) -> dict:
+        """Plans the whole turn for the v1 protocol state."""
+        self.player = state['player']
+        self.game = [[None if c is None else self.from_state_cell(c)
+                      for c in row] for row in state['board']['cells']]
+        me = state['players'][self.player]
+        self.points = me['points']
+
+        attacks, upgrades = [], []
+        if state['phase'] == 'attack':
+            while len(attacks) < MAX_PLAN_ACTIONS:
+                self.actions = {'attack': None, 'upgrade': None}
+                self.doTurn()
+                if self.actions['attack'] is None:
+                    break
+                cell, to = self.actions['attack']
+                attacks.append({'from': self.to_coords(cell),
+                                'to': self.to_coords(to)})
+                self.apply_attack(cell, to)
+
+            self.points += int(self.count_owned() * me['income'])
+
+        while len(upgrades) < MAX_PLAN_ACTIONS:
+            self.actions = {'attack': None, 'upgrade': None}
+            self.doUpgrade()
+            if self.actions['upgrade'] is None:
+                break
+            cell = tuple(self.actions['upgrade'])
+            upgrades.append({'cell': self.to_coords(cell), 'levels': 1})
+            self.apply_upgrade(cell)
+
+        return {'attacks': attacks, 'upgrades': upgrades}
+
+    @staticmethod
+    def from_state_cell(cell: dict) -> dict:
+        result = {'power': cell['power'], 'level': cell['level']}
+        if cell['owner'] is not None:
+            result['owner_id'] = cell['owner']
+        return result
+
+    @staticmethod
+    def to_coords(cell: tuple) -> dict:
+        return {'row': cell[0], 'col': cell[1]}
+
+    def count_owned(self) -> int:
+        return sum(1 for row in self.game for cell in row
+                   if cell is not None and cell.get('owner_id') == self.player)
+
+    def apply_attack(self, cell: tuple, to: tuple) -> None:
+        attacker, target = self.get_cell(cell), self.get_cell(to)
+        target['power'] -= attacker['power']
+        if target['power'] < 0:
+            target['power'] = -target['power']
+            target['owner_id'] = self.player
+            target['level'] = 1
+        attacker['power'] = 1
+
+    def apply_upgrade(self, cell: tuple) -> None:
+        target = self.get_cell(cell)
+        self.points -= target['level'] * (target['level'] + 1) // 2
+        target['level'] += 1
+
     def doTurn(self) -> None:
         best_score, best_from, best_to = 0, (0, 0), (0, 0)
         for row in range(len(self.game)):
@@ -3,10 +3,30 @@ from fastapi import FastAPI, HTTPException
 
 from game import Game
 
+PROTOCOL_VERSION = 1
+
 app = FastAPI()
 game = Game()
 
 
+@app.post('/v1/hello')
+async def hello(data: dict):
+    if PROTOCOL_VERSION not in data.get('versions', []):
+        raise HTTPException(status_code=400, detail='unsupported protocol')
+    return {
+        'version': PROTOCOL_VERSION,
+        'name': 'python-greedy',
+        'capabilities': ['turn_plan'],
+    }
+
+
+@app.post('/v1/plan')
+async def plan(data: dict):
+    return game.plan(data)
+
+
+# Legacy single-move endpoints
+
 @app.post('/ai_attack')
 async def receive_json(data: dict):
     game.reinit(data)